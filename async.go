@@ -0,0 +1,250 @@
+package slogan
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Asynchronous writing queue, nil if logging is synchronous
+var asyncQueue chan func()
+var asyncDone chan struct{}
+var asyncMu sync.RWMutex
+
+// Serializes queueing, so that entries are queued in order of rendering. Taken before outputsMu.
+var queueMu sync.Mutex
+
+// Goroutine ID of background writer, 0 if none, and queue it reads (only used by background writer)
+var drainID atomic.Uint64
+var drainQueue chan func()
+
+// Stop function of periodic flush of buffered outputs, if any
+var stopFlushTicker func()
+var flushTickerMu sync.Mutex
+
+// Write log entries asynchronously through a queue of bufSize entries, by a background goroutine.
+// Formatting is still done synchronously. A zero bufSize restores synchronous writing,
+// pending entries being written first.
+func SetAsync(bufSize int) {
+	asyncMu.Lock()
+	defer asyncMu.Unlock()
+	stopAsync()
+	startAsync(bufSize)
+}
+
+// Write log entries asynchronously like SetAsync, until ctx is cancelled.
+// Cancellation writes pending entries and restores synchronous writing, as Close does.
+func SetAsyncContext(ctx context.Context, bufSize int) {
+	asyncMu.Lock()
+	defer asyncMu.Unlock()
+	stopAsync()
+	startAsync(bufSize)
+	if asyncQueue == nil {
+		return
+	}
+	q, done := asyncQueue, asyncDone
+	go func() {
+		select {
+		case <-ctx.Done():
+			asyncMu.Lock()
+			// queue may have been replaced meanwhile
+			if asyncQueue == q {
+				stopAsync()
+			}
+			asyncMu.Unlock()
+		case <-done:
+		}
+	}()
+}
+
+// Flush buffered outputs, i.e having a Flush method (e.g *bufio.Writer), at least every d,
+// bounding staleness of logs. Flush is queued after pending entries if logging is asynchronous.
+// A zero or negative d stops periodic flush.
+func SetAsyncFlushInterval(d time.Duration) {
+	flushTickerMu.Lock()
+	defer flushTickerMu.Unlock()
+	if stopFlushTicker != nil {
+		stopFlushTicker()
+		stopFlushTicker = nil
+	}
+	if d <= 0 {
+		return
+	}
+	t := time.NewTicker(d)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-t.C:
+				flushOutputs()
+			case <-done:
+				return
+			}
+		}
+	}()
+	stopFlushTicker = func() {
+		t.Stop()
+		close(done)
+	}
+}
+
+// Call Flush on every output implementing it, in order with entries being written. Errors are ignored.
+func flushOutputs() {
+	d := startDelivery()
+	outputsMu.Lock()
+	ws := outputWriters()
+	after := d.deliver(func() {
+		for _, w := range ws {
+			if f, ok := w.(interface{ Flush() error }); ok {
+				f.Flush()
+			}
+		}
+	}, nil)
+	outputsMu.Unlock()
+	if after != nil {
+		after()
+	}
+}
+
+// Start background writer if bufSize > 0. asyncMu must be locked.
+func startAsync(bufSize int) {
+	if bufSize > 0 {
+		asyncQueue = make(chan func(), bufSize)
+		asyncDone = make(chan struct{})
+		go drain(asyncQueue, asyncDone)
+	}
+}
+
+// Background writer of asynchronous queue
+func drain(q chan func(), done chan struct{}) {
+	drainQueue = q
+	drainID.Store(goroutineID())
+	for f := range q {
+		f()
+	}
+	drainID.Store(0)
+	close(done)
+}
+
+// Whether caller runs on background writer, e.g a sink or subscriber logging in turn.
+// It costs a stack capture, only when logging is asynchronous.
+func onDrain() bool {
+	id := drainID.Load()
+	return id != 0 && id == goroutineID()
+}
+
+// Stop asynchronous writing, after pending entries are written. asyncMu must be locked.
+func stopAsync() {
+	if asyncQueue != nil {
+		close(asyncQueue)
+		<-asyncDone
+		asyncQueue = nil
+	}
+}
+
+// Delivery of a log entry, see startDelivery
+type delivery struct {
+	// queue if logging is asynchronous, nil otherwise
+	queue chan func()
+}
+
+// Start delivery of a log entry, before outputsMu is locked. If logging is asynchronous, queueing
+// is reserved until function returned by deliver is called, so that entries are queued in order.
+// Entries logged from background writer (e.g by a sink) are written synchronously, as it cannot queue to itself.
+func startDelivery() delivery {
+	if onDrain() {
+		return delivery{}
+	}
+	asyncMu.RLock()
+	if asyncQueue == nil {
+		asyncMu.RUnlock()
+		return delivery{}
+	}
+	queueMu.Lock()
+	return delivery{queue: asyncQueue}
+}
+
+// Do writes of an entry immediately if logging is synchronous, outputsMu being locked by caller,
+// and return notification. Otherwise return queueing of writes then notification. Returned function,
+// if any, is to be called once outputsMu is unlocked, so that a full queue does not block background writer
+// needing outputsMu for entries logged by sink or subscribers.
+func (d delivery) deliver(writes func(), notify func()) func() {
+	if d.queue == nil {
+		if writes != nil {
+			writes()
+		}
+		return notify
+	}
+	return func() {
+		d.queue <- func() {
+			if writes != nil {
+				writes()
+			}
+			if notify != nil {
+				notify()
+			}
+		}
+		queueMu.Unlock()
+		asyncMu.RUnlock()
+	}
+}
+
+// Wait until all pending asynchronous entries are written.
+// If SyncOnFlush=true, outputs having a Sync method (e.g *os.File) are then synced to disk.
+func Flush() {
+	if onDrain() {
+		// called by a queued function, e.g a sink logging a fatal error : write pending entries inline
+		drainPending()
+		if SyncOnFlush {
+			syncOutputs()
+		}
+		return
+	}
+	asyncMu.RLock()
+	if asyncQueue != nil {
+		ack := make(chan struct{})
+		asyncQueue <- func() { close(ack) }
+		<-ack
+	}
+	asyncMu.RUnlock()
+	if SyncOnFlush {
+		syncOutputs()
+	}
+}
+
+// Run functions pending in queue of background writer, from background writer itself
+func drainPending() {
+	for {
+		select {
+		case f, ok := <-drainQueue:
+			if !ok {
+				return
+			}
+			f()
+		default:
+			return
+		}
+	}
+}
+
+// Call Sync on every output implementing it, errors are ignored
+func syncOutputs() {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	for _, w := range outputWriters() {
+		if s, ok := w.(interface{ Sync() error }); ok {
+			s.Sync()
+		}
+	}
+}
+
+// Output, audit output and additional outputs. outputsMu must be locked.
+func outputWriters() []io.Writer {
+	ws := []io.Writer{output, auditOutput}
+	for _, o := range outputs.targets() {
+		ws = append(ws, o.w)
+	}
+	return ws
+}
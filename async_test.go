@@ -0,0 +1,223 @@
+package slogan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Writer slow enough for asynchronous entries to be pending, safe for concurrent use
+type slowWriter struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(5 * time.Millisecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.String()
+}
+
+func TestFatalFlushesAsync(t *testing.T) {
+	setup(t)
+	var w slowWriter
+	SetOutput(&w)
+	SetAsync(1)
+	SetExitOnError(true)
+	written := ""
+	ExitFunc = func(int) { written = w.String() }
+	Warning("first")
+	Warning("second")
+	Error("cause")
+	if !strings.Contains(written, "first") || !strings.Contains(written, "cause") || !strings.Contains(written, "Immediate exit with code 4") {
+		t.Errorf("pending entries should be written before exit, got %q", written)
+	}
+}
+
+// File recording calls to Sync
+type syncFile struct {
+	*os.File
+	synced int
+}
+
+func (f *syncFile) Sync() error {
+	f.synced++
+	return f.File.Sync()
+}
+
+func TestFlushSync(t *testing.T) {
+	setup(t)
+	f, err := os.Create(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sf := &syncFile{File: f}
+	SetOutput(sf)
+	Error("boom")
+	Flush()
+	if sf.synced != 0 {
+		t.Fatalf("Flush should not sync by default")
+	}
+	SetSyncOnFlush(true)
+	Flush()
+	if sf.synced != 1 {
+		t.Errorf("Flush should sync file output once, got %d", sf.synced)
+	}
+}
+
+func TestAsyncContext(t *testing.T) {
+	setup(t)
+	var w slowWriter
+	SetOutput(&w)
+	ctx, cancel := context.WithCancel(context.Background())
+	SetAsyncContext(ctx, 16)
+	asyncMu.RLock()
+	done := asyncDone
+	asyncMu.RUnlock()
+	for i := 0; i < 5; i++ {
+		Error(fmt.Sprintf("line%d", i))
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("drainer should exit on cancellation")
+	}
+	// synchronous again after cancellation
+	deadline := time.Now().Add(time.Second)
+	for {
+		asyncMu.RLock()
+		q := asyncQueue
+		asyncMu.RUnlock()
+		if q == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("logging should be synchronous after cancellation")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := strings.Count(w.String(), "line"); n != 5 {
+		t.Errorf("remaining lines should be written, got %d in %q", n, w.String())
+	}
+}
+
+func TestAsyncFlushInterval(t *testing.T) {
+	setup(t)
+	var w slowWriter
+	bw := bufio.NewWriterSize(&w, 4096)
+	SetOutput(bw)
+	SetAsync(16)
+	SetAsyncFlushInterval(10 * time.Millisecond)
+	Error("tick")
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(w.String(), "tick") {
+		if time.Now().After(deadline) {
+			t.Fatalf("buffered entry should be flushed periodically")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Fail if f does not return in time
+func within(t *testing.T, d time.Duration, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(d):
+		t.Fatalf("deadlock : no return after %s", d)
+	}
+}
+
+func TestAsyncSinkLogging(t *testing.T) {
+	setup(t)
+	var mu sync.Mutex
+	var got []string
+	SetSink(func(level int, rendered string) {
+		mu.Lock()
+		got = append(got, rendered)
+		mu.Unlock()
+		if level == Lerror {
+			Warning("from sink")
+		}
+	})
+	SetAsync(4)
+	within(t, 5*time.Second, func() {
+		for i := 0; i < 20; i++ {
+			Error("boom")
+		}
+		SetAsync(0)
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 40 {
+		t.Errorf("want 40 entries, got %d", len(got))
+	}
+}
+
+func TestAsyncSinkClose(t *testing.T) {
+	setup(t)
+	SetSink(func(level int, rendered string) {
+		if level == Lerror {
+			Warning("from sink")
+		}
+	})
+	SetAsync(4)
+	within(t, 5*time.Second, func() {
+		for i := 0; i < 20; i++ {
+			Error("boom")
+		}
+		Close()
+	})
+}
+
+func TestFlushFromBackgroundWriter(t *testing.T) {
+	setup(t)
+	code := fakeExit(t)
+	SetExitOnError(true)
+	var mu sync.Mutex
+	var got []string
+	SetSink(func(level int, rendered string) {
+		mu.Lock()
+		got = append(got, StripANSI(rendered))
+		mu.Unlock()
+		if strings.HasSuffix(rendered, "cause") {
+			// fatal path flushes from background writer
+			Error("fatal from sink")
+		}
+	})
+	SetAsync(4)
+	within(t, 5*time.Second, func() {
+		for i := 0; i < 10; i++ {
+			Warning("cause")
+		}
+		Warning("last")
+		Flush()
+		SetAsync(0)
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if *code != Lerror || len(got) != 31 || !strings.HasSuffix(got[len(got)-1], "last") {
+		t.Errorf("flush from background writer should write pending entries, got exit %d, %q", *code, got)
+	}
+}
@@ -0,0 +1,371 @@
+package slogan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Configuration of slogan, mirroring package options
+type Config struct {
+	Verbosity         int       // see SetVerbosity
+	ExitOnError       bool      // see SetExitOnError
+	WarningAsError    bool      // see SetWarningAsError
+	TraceCaller       bool      // see SetTraceCaller
+	CallerBase        bool      // show only basename of caller
+	CallerDepth       int       // see SetCallerDepth
+	CallerMinLevel    int       // see SetCallerMinLevel
+	CallerPackage     bool      // see SetCallerPackage
+	CallerSkipRuntime bool      // see SetCallerSkipRuntime
+	Colorize          bool      // see SetColor
+	ForceColorize     bool      // see SetForceColor
+	EffectFallback    bool      // see SetEffectFallback
+	NoEmpty           bool      // see SetNoEmpty
+	CompactTags       bool      // see SetCompactTags
+	SyntaxHighlight   bool      // see SetSyntaxHighlight
+	JSON              bool      // see SetJSON
+	OTelSeverity      bool      // see SetOTelSeverity
+	ShowElapsed       bool      // see SetShowElapsed
+	ShowGoroutine     bool      // see SetShowGoroutine
+	ShowHost          bool      // see SetShowHost
+	ShowPID           bool      // see SetShowPID
+	MaxMessageBytes   int       // see SetMaxMessageBytes
+	Sanitize          bool      // see SetSanitize
+	ShowDelta         bool      // see SetShowDelta
+	ShowSeq           bool      // see SetShowSeq
+	SilentToHooks     bool      // see SetSilentToHooks
+	DiffColors        bool      // see SetDiffColors
+	LevelMask         uint      // see SetLevelMask
+	Emoji             bool      // see SetEmoji
+	AlignMultiline    bool      // see SetAlignMultiline
+	SyncOnFlush       bool      // see SetSyncOnFlush
+	Prefix            string    // see SetPrefix
+	Flags             int       // legacy log package flags (date, time...)
+	TimeFormat        string    // time format of layout (see SetLayout), empty keeps current one
+	Output            io.Writer `json:"-"` // see SetOutput, nil keeps current output
+}
+
+// Configuration lock, serializing Configure, CurrentConfig and Reset
+var configMu sync.Mutex
+
+// Apply a whole configuration with one call, atomically for rendering : options are set under the lock
+// taken by writing of entries, which are all rendered either with former or with new configuration.
+// Verbosity is set first, so that an entry may be gated by new verbosity but rendered with former options.
+func Configure(c Config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	// may log a clamp notice, so before locking outputs
+	SetVerbosity(c.Verbosity)
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	ExitOnError = c.ExitOnError
+	WarningAsError = c.WarningAsError
+	TraceCaller = c.TraceCaller
+	CallerBase = c.CallerBase
+	CallerDepth = c.CallerDepth
+	CallerMinLevel = c.CallerMinLevel
+	CallerPackage = c.CallerPackage
+	CallerSkipRuntime = c.CallerSkipRuntime
+	Colorize = c.Colorize
+	ForceColorize = c.ForceColorize
+	EffectFallback = c.EffectFallback
+	NoEmpty = c.NoEmpty
+	CompactTags = c.CompactTags
+	SyntaxHighlight = c.SyntaxHighlight
+	JSON = c.JSON
+	OTelSeverity = c.OTelSeverity
+	ShowElapsed = c.ShowElapsed
+	ShowGoroutine = c.ShowGoroutine
+	ShowHost = c.ShowHost
+	ShowPID = c.ShowPID
+	MaxMessageBytes = c.MaxMessageBytes
+	Sanitize = c.Sanitize
+	ShowDelta = c.ShowDelta
+	ShowSeq = c.ShowSeq
+	SilentToHooks = c.SilentToHooks
+	DiffColors = c.DiffColors
+	LevelMask = c.LevelMask
+	Emoji = c.Emoji
+	AlignMultiline = c.AlignMultiline
+	SyncOnFlush = c.SyncOnFlush
+	tags[0] = c.Prefix
+	setFlags(c.Flags)
+	if c.TimeFormat != "" {
+		f := copyFormats(formats)
+		f["layouttime"] = c.TimeFormat
+		formats = f
+	}
+	if c.Output != nil {
+		isTerminal = isTerminalWriter(c.Output)
+		output = c.Output
+		logger.SetOutput(c.Output)
+	}
+}
+
+// Snapshot of current configuration
+func CurrentConfig() Config {
+	configMu.Lock()
+	defer configMu.Unlock()
+	return Config{
+		Verbosity:         GetVerbosity(),
+		ExitOnError:       ExitOnError,
+		WarningAsError:    WarningAsError,
+		TraceCaller:       TraceCaller,
+		CallerBase:        CallerBase,
+		CallerDepth:       CallerDepth,
+		CallerMinLevel:    CallerMinLevel,
+		CallerPackage:     CallerPackage,
+		CallerSkipRuntime: CallerSkipRuntime,
+		Colorize:          Colorize,
+		ForceColorize:     ForceColorize,
+		EffectFallback:    EffectFallback,
+		NoEmpty:           NoEmpty,
+		CompactTags:       CompactTags,
+		SyntaxHighlight:   SyntaxHighlight,
+		JSON:              JSON,
+		OTelSeverity:      OTelSeverity,
+		ShowElapsed:       ShowElapsed,
+		ShowGoroutine:     ShowGoroutine,
+		ShowHost:          ShowHost,
+		ShowPID:           ShowPID,
+		MaxMessageBytes:   MaxMessageBytes,
+		Sanitize:          Sanitize,
+		ShowDelta:         ShowDelta,
+		ShowSeq:           ShowSeq,
+		SilentToHooks:     SilentToHooks,
+		DiffColors:        DiffColors,
+		LevelMask:         LevelMask,
+		Emoji:             Emoji,
+		AlignMultiline:    AlignMultiline,
+		SyncOnFlush:       SyncOnFlush,
+		Prefix:            tags[0],
+		Flags:             logger.Flags(),
+		TimeFormat:        formats["layouttime"],
+		Output:            output,
+	}
+}
+
+// Defaults, captured at init
+var defaultConfig Config
+var defaultTags [10]string
+var defaultCompactTags [10]string
+var defaultColors map[int]string
+var defaultFormats map[string]string
+var defaultParts map[string]bool
+var defaultEmojis [10]string
+var defaultIsTerminal bool
+
+func init() {
+	// terminal capabilities are part of defaults
+	initTerminal()
+	defaultConfig = CurrentConfig()
+	defaultTags = tags
+	defaultCompactTags = compactTags
+	defaultEmojis = emojis
+	defaultColors = copyColors(colors)
+	defaultFormats = copyFormats(formats)
+	defaultParts = copyParts(parts)
+	defaultIsTerminal = isTerminal
+}
+
+// Restore all defaults : options, maps, output (stderr), prefix, time references and counters
+func Reset() {
+	c := defaultConfig
+	c.Output = os.Stderr
+	Configure(c)
+	outputs.clear()
+	SetSink(nil)
+	SetEventSink(nil)
+	SetAuditOutput(nil)
+	SetAsync(0)
+	SetAsyncFlushInterval(0)
+	configMu.Lock()
+	defer configMu.Unlock()
+	isTerminal = defaultIsTerminal
+	tags = defaultTags
+	compactTags = defaultCompactTags
+	emojis = defaultEmojis
+	prefixFunc = nil
+	layout = nil
+	ResetSeq()
+	outputsMu.Lock()
+	lastEntry = time.Time{}
+	outputsMu.Unlock()
+	atomic.StoreInt32(&highest, 0)
+	customLevelsMu.Lock()
+	customLevels = map[int]customLevel{}
+	customLevelsMu.Unlock()
+	colors = copyColors(defaultColors)
+	formats = copyFormats(defaultFormats)
+	parts = copyParts(defaultParts)
+	remapsMu.Lock()
+	remaps = map[int]int{}
+	remapsMu.Unlock()
+	atomic.StoreInt32(&maxLevel, Lsilent)
+	levelPrefixesMu.Lock()
+	levelPrefixes = map[int]string{}
+	levelPrefixesMu.Unlock()
+	throttlesMu.Lock()
+	throttles = map[int]*throttle{}
+	throttlesMu.Unlock()
+	quietsMu.Lock()
+	quiets = map[int]*quietHours{}
+	quietsMu.Unlock()
+	dropsMu.Lock()
+	drops = nil
+	dropped = 0
+	dropsMu.Unlock()
+	ClearCallerIgnores()
+	collectedMu.Lock()
+	collected = map[int][]string{}
+	collectedMu.Unlock()
+	summariesMu.Lock()
+	summaries = map[string]int{}
+	summariesMu.Unlock()
+	ResetOnce()
+	nowFunc = time.Now
+	sinceFunc = time.Since
+	ExitFunc = os.Exit
+	resetStart()
+	resetLast()
+}
+
+func copyColors(m map[int]string) map[int]string {
+	n := make(map[int]string, len(m))
+	for k, v := range m {
+		n[k] = v
+	}
+	return n
+}
+
+func copyFormats(m map[string]string) map[string]string {
+	n := make(map[string]string, len(m))
+	for k, v := range m {
+		n[k] = v
+	}
+	return n
+}
+
+func copyParts(m map[string]bool) map[string]bool {
+	n := make(map[string]bool, len(m))
+	for k, v := range m {
+		n[k] = v
+	}
+	return n
+}
+
+// Human readable dump of effective configuration, e.g for a diagnostic option of a program
+func ConfigString() string {
+	c := CurrentConfig()
+	asyncMu.RLock()
+	async := asyncQueue != nil
+	asyncMu.RUnlock()
+	outputsMu.Lock()
+	extra := outputs.Len()
+	audit := auditOutput != nil
+	outputsMu.Unlock()
+	minLevel := "all"
+	if c.CallerMinLevel > Lsilent {
+		minLevel = levelName(c.CallerMinLevel)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "verbosity: %s (%d)\n", levelName(c.Verbosity), c.Verbosity)
+	fmt.Fprintf(&b, "color: %t (forced: %t, terminal: %t)\n", c.Colorize, c.ForceColorize, IsTerminal())
+	fmt.Fprintf(&b, "caller: %t (base: %t, depth: %d, min level: %s, package: %t, skip runtime: %t)\n",
+		c.TraceCaller, c.CallerBase, c.CallerDepth, minLevel, c.CallerPackage, c.CallerSkipRuntime)
+	fmt.Fprintf(&b, "output: %T (additional: %d, audit: %t)\n", c.Output, extra, audit)
+	modes := []string{}
+	for _, m := range []struct {
+		name string
+		on   bool
+	}{
+		{"json", c.JSON}, {"async", async}, {"exit_on_error", c.ExitOnError}, {"warning_as_error", c.WarningAsError},
+		{"no_empty", c.NoEmpty}, {"compact_tags", c.CompactTags}, {"emoji", c.Emoji}, {"syntax_highlight", c.SyntaxHighlight},
+		{"elapsed", c.ShowElapsed}, {"host", c.ShowHost}, {"pid", c.ShowPID}, {"goroutine", c.ShowGoroutine},
+	} {
+		if m.on {
+			modes = append(modes, m.name)
+		}
+	}
+	fmt.Fprintf(&b, "modes: %s\n", strings.Join(modes, " "))
+	return b.String()
+}
+
+// Structured dump of configuration, with maps of settings
+type configDump struct {
+	Config
+	OutputType     string
+	Tags           [10]string
+	Colors         map[int]string
+	Formats        map[string]string
+	ColorizedParts map[string]bool
+	LevelPrefixes  map[int]string
+	LevelRemaps    map[int]int
+}
+
+// Write effective configuration as indented JSON, including tags, colors, formats, colorized parts,
+// level prefixes and remaps, e.g to be attached to a bug report. Output is only given by its type.
+func DumpConfig(w io.Writer) error {
+	d := configDump{
+		Config:         CurrentConfig(),
+		Tags:           tags,
+		Colors:         colors,
+		Formats:        formats,
+		ColorizedParts: parts,
+	}
+	levelPrefixesMu.RLock()
+	d.LevelPrefixes = make(map[int]string, len(levelPrefixes))
+	for level, prefix := range levelPrefixes {
+		d.LevelPrefixes[level] = prefix
+	}
+	levelPrefixesMu.RUnlock()
+	remapsMu.RLock()
+	d.LevelRemaps = make(map[int]int, len(remaps))
+	for from, to := range remaps {
+		d.LevelRemaps[from] = to
+	}
+	remapsMu.RUnlock()
+	d.OutputType = fmt.Sprintf("%T", d.Output)
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("slogan: %w", err)
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// List differences between two configurations, one "Field: a -> b" line per differing field
+func DiffConfig(a, b Config) []string {
+	diffs := []string{}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		fa, fb := va.Field(i).Interface(), vb.Field(i).Interface()
+		if reflect.DeepEqual(fa, fb) {
+			continue
+		}
+		if va.Field(i).Kind() == reflect.Interface {
+			diffs = append(diffs, fmt.Sprintf("%s: %T -> %T", va.Type().Field(i).Name, fa, fb))
+			continue
+		}
+		diffs = append(diffs, fmt.Sprintf("%s: %v -> %v", va.Type().Field(i).Name, fa, fb))
+	}
+	return diffs
+}
+
+// Name of a level, i.e trimmed tag
+func levelName(level int) string {
+	_, custom := customLevelOf(level)
+	switch {
+	case level == Lsilent:
+		return "silent"
+	case level == Laudit || (level > Lsilent && level < len(tags)) || custom:
+		return strings.TrimSpace(tagOf(level))
+	}
+	return "unknown"
+}
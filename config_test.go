@@ -0,0 +1,119 @@
+package slogan
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigure(t *testing.T) {
+	setup(t)
+	var extra bytes.Buffer
+	AddOutput(&extra)
+	var b bytes.Buffer
+	c := Config{Verbosity: Ldebug, Colorize: true, TraceCaller: true, CallerBase: true, CallerDepth: 2, JSON: true, ShowPID: true, MaxMessageBytes: 100, Prefix: "app: ", Flags: log.Ldate, TimeFormat: "15:04", Output: &b}
+	Configure(c)
+	if got := CurrentConfig(); !reflect.DeepEqual(got, c) {
+		t.Errorf("configuration not read back\nwant %+v\ngot  %+v", c, got)
+	}
+	// flags apply to all outputs
+	for _, o := range outputs.targets() {
+		if o.l.Flags() != log.Ldate {
+			t.Errorf("flags of additional output not set : %d", o.l.Flags())
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	setup(t)
+	SetVerbosity(Ltrace)
+	SetJSON(true)
+	SetShowPID(true)
+	SetPrefix("app: ")
+	colors[Lerror] = "Blue"
+	formats["field"] = " %s:%v"
+	SetLevelRemap(Linfo, Ldebug)
+	SetLevelPrefix(Lerror, "[E] ")
+	AddCallerIgnore("vendor/")
+	restore := WithMaxLevel(Ldebug)
+	defer restore()
+	Reset()
+	want := defaultConfig
+	want.Output = os.Stderr
+	if got := CurrentConfig(); !reflect.DeepEqual(got, want) {
+		t.Errorf("options not restored\nwant %+v\ngot  %+v", want, got)
+	}
+	if colors[Lerror] != defaultColors[Lerror] || formats["field"] != defaultFormats["field"] || tags != defaultTags {
+		t.Errorf("maps not restored")
+	}
+	if effectiveLevel(Linfo) != Linfo || levelPrefix(Lerror) != "" || len(callerIgnores) != 0 {
+		t.Errorf("remaps, level prefixes, maximum level or caller ignores not restored")
+	}
+}
+
+func TestConfigString(t *testing.T) {
+	setup(t)
+	SetVerbosity(Ldebug)
+	SetJSON(true)
+	s := ConfigString()
+	if !strings.Contains(s, "verbosity: debug (8)") || !strings.Contains(s, "json") || !strings.Contains(s, "output: *bytes.Buffer") {
+		t.Errorf("unexpected configuration dump %q", s)
+	}
+}
+
+func TestDumpConfig(t *testing.T) {
+	setup(t)
+	SetVerbosity(Ldebug)
+	SetLevelPrefix(Lerror, "E")
+	var b bytes.Buffer
+	if err := DumpConfig(&b); err != nil {
+		t.Fatal(err)
+	}
+	var c Config
+	if err := json.Unmarshal(b.Bytes(), &c); err != nil {
+		t.Fatalf("%v : %q", err, b.String())
+	}
+	cur := CurrentConfig()
+	c.Output = cur.Output
+	if diffs := DiffConfig(cur, c); len(diffs) != 0 {
+		t.Errorf("dumped config should unmarshal back, got differences %q", diffs)
+	}
+	if !strings.Contains(b.String(), `"E"`) {
+		t.Errorf("dump should include level prefixes, got %q", b.String())
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	a := Config{Verbosity: Lwarning}
+	b := a
+	b.Verbosity = Ldebug
+	if diffs := DiffConfig(a, b); !reflect.DeepEqual(diffs, []string{"Verbosity: 5 -> 8"}) {
+		t.Errorf("unexpected differences %q", diffs)
+	}
+	if diffs := DiffConfig(a, a); len(diffs) != 0 {
+		t.Errorf("same config should have no difference, got %q", diffs)
+	}
+}
+
+func TestConfigureTimeFormat(t *testing.T) {
+	b := setup(t)
+	SetClock(func() time.Time { return time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC) })
+	SetLayout([]string{"time", "tag", "caller", "msg"})
+	c := CurrentConfig()
+	c.TimeFormat = "15h04"
+	Configure(c)
+	Error("x")
+	if got := strings.TrimSpace(b.String()); !strings.HasPrefix(got, "12h30 error") {
+		t.Errorf("time format should apply to layout, got %q", got)
+	}
+	c.TimeFormat = ""
+	Configure(c)
+	if CurrentConfig().TimeFormat != "15h04" {
+		t.Errorf("empty time format should keep current one")
+	}
+}
@@ -0,0 +1,106 @@
+package slogan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Configuration file content. Missing keys are nil and keep current values.
+type fileConfig struct {
+	Level          interface{} `json:"level"` // level name (e.g "debug") or number
+	Color          *bool       `json:"color"`
+	ForceColor     *bool       `json:"force_color"`
+	JSON           *bool       `json:"json"`
+	Caller         *bool       `json:"caller"`
+	CallerDepth    *int        `json:"caller_depth"`
+	ExitOnError    *bool       `json:"exit_on_error"`
+	WarningAsError *bool       `json:"warning_as_error"`
+	NoEmpty        *bool       `json:"no_empty"`
+	CompactTags    *bool       `json:"compact_tags"`
+	Prefix         *string     `json:"prefix"`
+	Theme          *string     `json:"theme"`
+	Output         *string     `json:"output"` // file path, appended
+}
+
+// Output file opened by LoadConfigFile, closed when replaced by another load or by Close
+var fileOutput *os.File
+
+// Load configuration from a JSON file, e.g {"level": "debug", "caller": true, "output": "/var/log/app.log"}.
+// Missing keys keep current values. Output file of a former load is closed. Nothing is applied if file cannot be read or parsed.
+func LoadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var fc fileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return fmt.Errorf("slogan: %s: %w", path, err)
+	}
+	level := GetVerbosity()
+	switch l := fc.Level.(type) {
+	case nil:
+	case float64:
+		level = int(l)
+	case string:
+		var ok bool
+		if level, ok = levelFromName(l); !ok {
+			return fmt.Errorf("slogan: %s: unknown level %q", path, l)
+		}
+	default:
+		return fmt.Errorf("slogan: %s: invalid level %v", path, l)
+	}
+	if fc.Theme != nil {
+		if _, ok := themes[*fc.Theme]; !ok {
+			return fmt.Errorf("slogan: %s: unknown theme %q", path, *fc.Theme)
+		}
+	}
+	if fc.Output != nil {
+		f, err := os.OpenFile(*fc.Output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		SetOutput(f)
+		if fileOutput != nil {
+			// pending asynchronous entries may still target former file
+			Flush()
+			fileOutput.Close()
+		}
+		fileOutput = f
+	}
+	SetVerbosity(level)
+	if fc.Color != nil {
+		SetColor(*fc.Color)
+	}
+	if fc.ForceColor != nil {
+		SetForceColor(*fc.ForceColor)
+	}
+	if fc.JSON != nil {
+		SetJSON(*fc.JSON)
+	}
+	if fc.Caller != nil {
+		SetTraceCaller(*fc.Caller)
+	}
+	if fc.CallerDepth != nil {
+		SetCallerDepth(*fc.CallerDepth)
+	}
+	if fc.ExitOnError != nil {
+		SetExitOnError(*fc.ExitOnError)
+	}
+	if fc.WarningAsError != nil {
+		SetWarningAsError(*fc.WarningAsError)
+	}
+	if fc.NoEmpty != nil {
+		SetNoEmpty(*fc.NoEmpty)
+	}
+	if fc.CompactTags != nil {
+		SetCompactTags(*fc.CompactTags)
+	}
+	if fc.Prefix != nil {
+		SetPrefix(*fc.Prefix)
+	}
+	if fc.Theme != nil {
+		SetTheme(*fc.Theme)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package slogan
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	setup(t)
+	dir := t.TempDir()
+	log := filepath.Join(dir, "app.log")
+	conf := filepath.Join(dir, "slogan.json")
+	content := `{"level": "debug", "caller": true, "compact_tags": true, "prefix": "app: ", "output": "` + filepath.ToSlash(log) + `"}`
+	if err := os.WriteFile(conf, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfigFile(conf); err != nil {
+		t.Fatal(err)
+	}
+	if GetVerbosity() != Ldebug || TraceCaller != true || CompactTags != true || tags[0] != "app: " || Colorize != true {
+		t.Errorf("settings not applied : %+v", CurrentConfig())
+	}
+	Error("boom")
+	Close()
+	if data, _ := os.ReadFile(log); !strings.Contains(string(data), "boom") {
+		t.Errorf("output file not used, got %q", data)
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	setup(t)
+	dir := t.TempDir()
+	for _, content := range []string{`{"level": `, `{"level": "loud"}`, `{"theme": "neon"}`} {
+		conf := filepath.Join(dir, "slogan.json")
+		if err := os.WriteFile(conf, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := LoadConfigFile(conf); err == nil {
+			t.Errorf("%s : want error", content)
+		}
+	}
+	if GetVerbosity() != Lwarning {
+		t.Errorf("nothing should be applied on error")
+	}
+}
+
+func TestLoadConfigFileJSON(t *testing.T) {
+	setup(t)
+	conf := filepath.Join(t.TempDir(), "slogan.json")
+	if err := os.WriteFile(conf, []byte(`{"json": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfigFile(conf); err != nil {
+		t.Fatal(err)
+	}
+	if JSON != true {
+		t.Errorf("json key should enable JSON output")
+	}
+}
+
+func TestLoadConfigFileClosesFormerOutput(t *testing.T) {
+	setup(t)
+	dir := t.TempDir()
+	load := func(name string) {
+		conf := filepath.Join(dir, "slogan.json")
+		content := `{"output": "` + filepath.ToSlash(filepath.Join(dir, name)) + `"}`
+		if err := os.WriteFile(conf, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := LoadConfigFile(conf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load("first.log")
+	first := fileOutput
+	load("second.log")
+	defer Close()
+	if _, err := first.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("former output file should be closed, got %v", err)
+	}
+	if fileOutput == first || output != fileOutput {
+		t.Errorf("new output file should be used")
+	}
+}
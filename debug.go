@@ -0,0 +1,71 @@
+//go:build !nodebug
+
+package slogan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Debug log
+func Debug(log string, kv ...interface{}) {
+	logKV(Ldebug, log, kv)
+}
+
+// Debug log
+func (l *Logger) Debug(log string, kv ...interface{}) {
+	logKV(Ldebug, log, kv)
+}
+
+// Debug log, formatted as fmt.Sprintf
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	Log(Ldebug, fmt.Sprintf(format, v...))
+}
+
+// Debug log computed lazily, see LogFunc
+func DebugFunc(fn func() string) {
+	logFunc(Ldebug, fn)
+}
+
+// Trace log
+// Use 'empty' format for empty thing to be trace
+func Trace(trace interface{}) {
+	if fmt.Sprintf("%v", trace) == "[]" {
+		Log(Ltrace, fmt.Sprintf(formats["empty"], trace))
+	} else {
+		Log(Ltrace, fmt.Sprintf(formats["trace"], trace))
+	}
+}
+
+// Trace log on a single compact line, type and value
+func TraceLine(trace interface{}) {
+	Log(Ltrace, fmt.Sprintf(formats["traceline"], trace))
+}
+
+// Trace log with chosen representations only, one line per verb, e.g TraceVerbs(v, "%T", "%+v").
+// Allowed verbs are %v, %+v, %#v, %T, %s, %q, %d, %x and %p. An invalid verb returns an error, nothing being logged.
+// No verb is same as Trace.
+func TraceVerbs(trace interface{}, verbs ...string) error {
+	if err := checkVerbs(verbs); err != nil {
+		return err
+	}
+	if len(verbs) == 0 {
+		Trace(trace)
+		return nil
+	}
+	lines := make([]string, len(verbs))
+	for i, v := range verbs {
+		lines[i] = fmt.Sprintf(formats["traceverb"], v, fmt.Sprintf(v, trace))
+	}
+	Log(Ltrace, strings.Join(lines, "\n"))
+	return nil
+}
+
+// Trace log with caller punctually
+func TraceCall(trace interface{}) {
+	TraceCaller = true
+	defer SetTraceCaller(false)
+	incr_offset()
+	defer decr_offset()
+	Trace(trace)
+}
@@ -0,0 +1,79 @@
+//go:build !nodebug
+
+package slogan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDebug(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Ltrace)
+	Debug("details", "n", 1)
+	Trace([]int{1, 2})
+	ls := lines(b)
+	if len(ls) < 2 || strings.TrimSpace(ls[0]) != "debug     details n=1" || !strings.Contains(b.String(), "trace") {
+		t.Errorf("debug and trace should be logged in default build, got %q", b.String())
+	}
+}
+
+func TestDebugFunc(t *testing.T) {
+	b := setup(t)
+	called := false
+	DebugFunc(func() string {
+		called = true
+		return "computed"
+	})
+	if called {
+		t.Fatalf("fn should not be called below verbosity")
+	}
+	SetVerbosity(Ldebug)
+	DebugFunc(func() string { return "computed" })
+	if got := strings.TrimSpace(b.String()); got != "debug     computed" {
+		t.Errorf("want computed debug message, got %q", got)
+	}
+}
+
+func TestMemStats(t *testing.T) {
+	b := setup(t)
+	MemStats()
+	if b.Len() != 0 {
+		t.Fatalf("memory stats should be gated at debug, got %q", b.String())
+	}
+	SetVerbosity(Ldebug)
+	MemStats()
+	if got := b.String(); !strings.Contains(got, "debug") || !strings.Contains(got, "ALLOC:") {
+		t.Errorf("want alloc field at debug level, got %q", got)
+	}
+}
+
+func TestTraceLine(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Ltrace)
+	TraceLine(struct {
+		Name string
+		N    int
+	}{"a", 1})
+	ls := lines(b)
+	if len(ls) != 1 || !strings.HasSuffix(ls[0], "type=struct { Name string; N int } value={Name:a N:1}") {
+		t.Errorf("want single compact trace line, got %q", b.String())
+	}
+}
+
+func TestTraceVerbs(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Ltrace)
+	type point struct{ X, Y int }
+	if err := TraceVerbs(point{1, 2}, "%T", "%+v"); err != nil {
+		t.Fatal(err)
+	}
+	ls := lines(b)
+	if len(ls) != 2 || !strings.Contains(ls[0], "slogan.point") || !strings.Contains(ls[1], "{X:1 Y:2}") {
+		t.Errorf("want exactly type and value lines, got %q", b.String())
+	}
+	b.Reset()
+	if err := TraceVerbs(point{}, "%T", "%z"); err == nil || b.Len() != 0 {
+		t.Errorf("invalid verb should be an error without logging, got %v, %q", err, b.String())
+	}
+}
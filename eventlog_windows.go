@@ -0,0 +1,64 @@
+//go:build windows
+
+package slogan
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Writer reporting entries to Windows Event Log
+type eventLogWriter struct {
+	l *eventlog.Log
+}
+
+// Return a writer reporting each entry to Windows Event Log under source, to be given to SetOutput.
+// Error and worse entries are reported as errors, warnings as warnings, others as information.
+// Source should have been registered (see eventlog.InstallAsEventCreate) for messages to be displayed properly.
+func NewEventLogWriter(source string) (io.Writer, error) {
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("slogan: event log %q: %w", source, err)
+	}
+	return &eventLogWriter{l: l}, nil
+}
+
+// Report an entry written directly, i.e not by slogan, as information
+func (w *eventLogWriter) Write(p []byte) (int, error) {
+	if err := w.writeLevel(Linfo, string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Report an entry of a level, see levelWriter
+func (w *eventLogWriter) writeLevel(level int, entry string) error {
+	msg := strings.TrimRight(StripANSI(entry), "\n")
+	switch eventType(level) {
+	case windows.EVENTLOG_ERROR_TYPE:
+		return w.l.Error(1, msg)
+	case windows.EVENTLOG_WARNING_TYPE:
+		return w.l.Warning(1, msg)
+	}
+	return w.l.Info(1, msg)
+}
+
+// Close event log, see Close
+func (w *eventLogWriter) Close() error {
+	return w.l.Close()
+}
+
+// Event log type of a level : error for error and worse, warning for warning, information otherwise
+func eventType(level int) uint16 {
+	switch {
+	case level > Lsilent && level <= Lerror:
+		return windows.EVENTLOG_ERROR_TYPE
+	case level == Lwarning:
+		return windows.EVENTLOG_WARNING_TYPE
+	}
+	return windows.EVENTLOG_INFORMATION_TYPE
+}
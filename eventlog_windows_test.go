@@ -0,0 +1,28 @@
+//go:build windows
+
+package slogan
+
+import (
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestEventType(t *testing.T) {
+	cases := map[int]uint16{
+		Lemergency: windows.EVENTLOG_ERROR_TYPE,
+		Lcritical:  windows.EVENTLOG_ERROR_TYPE,
+		Lerror:     windows.EVENTLOG_ERROR_TYPE,
+		Lwarning:   windows.EVENTLOG_WARNING_TYPE,
+		Lnotice:    windows.EVENTLOG_INFORMATION_TYPE,
+		Linfo:      windows.EVENTLOG_INFORMATION_TYPE,
+		Ltrace:     windows.EVENTLOG_INFORMATION_TYPE,
+		Lsilent:    windows.EVENTLOG_INFORMATION_TYPE,
+		Laudit:     windows.EVENTLOG_INFORMATION_TYPE,
+	}
+	for level, want := range cases {
+		if got := eventType(level); got != want {
+			t.Errorf("eventType(%d) = %d, want %d", level, got, want)
+		}
+	}
+}
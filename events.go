@@ -0,0 +1,41 @@
+package slogan
+
+import (
+	"time"
+)
+
+// Structured log entry, as appended by SetEventSink
+type Event struct {
+	Time   time.Time
+	Level  int
+	Msg    string
+	Caller string // file:line as configured (e.g SetCallerDepth), filled even if caller is not traced
+	Fields map[string]interface{}
+}
+
+// Slice receiving events, if any (guarded by outputsMu)
+var events *[]Event
+
+// Append each written log entry as an Event to slice, in addition to text outputs, e.g for typed assertions in tests.
+// Fields are key/value pairs given to level functions (e.g Info), message being without them.
+// Slice should be read once logging is done. A nil slice stops appending.
+func SetEventSink(e *[]Event) {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	events = e
+}
+
+// Build an event from a log entry
+func event(level int, msg string, fn_ string, line int, fields []field) Event {
+	e := Event{Time: nowFunc(), Level: level, Msg: msg}
+	if fn_ != "" {
+		e.Caller = callerWhere(fn_, line)
+	}
+	if len(fields) > 0 {
+		e.Fields = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			e.Fields[f.key] = f.value
+		}
+	}
+	return e
+}
@@ -0,0 +1,43 @@
+package slogan
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"testing"
+)
+
+func TestEventSink(t *testing.T) {
+	setup(t)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lsilent)
+	var events []Event
+	SetEventSink(&events)
+	t.Cleanup(func() { SetEventSink(nil) })
+	Error("boom", "id", 7)
+	_, file, line, _ := runtime.Caller(0)
+	if len(events) != 1 {
+		t.Fatalf("want one event, got %d", len(events))
+	}
+	e := events[0]
+	if want := fmt.Sprintf("%s:%d", path.Base(file), line-1); e.Caller != want {
+		t.Errorf("want caller %q, got %q", want, e.Caller)
+	}
+	if e.Level != Lerror || e.Msg != "boom" || e.Fields["id"] != 7 || e.Time.IsZero() {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
+
+func TestEventSinkCallerNotTraced(t *testing.T) {
+	setup(t)
+	var events []Event
+	SetEventSink(&events)
+	Error("boom")
+	_, file, line, _ := runtime.Caller(0)
+	if len(events) != 1 {
+		t.Fatalf("want one event, got %d", len(events))
+	}
+	if want := fmt.Sprintf("%s:%d", path.Base(file), line-1); events[0].Caller != want {
+		t.Errorf("want caller %q even if not traced, got %q", want, events[0].Caller)
+	}
+}
@@ -1,8 +1,11 @@
 module github.com/crownedgrouse/slogan
 
-go 1.12
+go 1.21
 
 require (
 	github.com/bclicn/color v0.0.0-20180711051946-108f2023dc84
 	golang.org/x/crypto v0.9.0
+	golang.org/x/sys v0.8.0
 )
+
+require golang.org/x/term v0.8.0 // indirect
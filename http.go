@@ -0,0 +1,54 @@
+package slogan
+
+import (
+	"net/http"
+	"time"
+)
+
+// Response writer recording status code
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap for http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Access log middleware : log method, path, status and duration of each request,
+// as info or as error for 5xx statuses. X-Request-ID header is added as a field if present.
+// Entries are logged from the server goroutine : if caller is traced, it is net/http server code, not a meaningful location.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		begin := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		level := Linfo
+		if sw.status >= 500 {
+			level = Lerror
+		}
+		kv := []interface{}{"status", sw.status, "duration", Duration(sinceFunc(begin))}
+		if id := r.Header.Get("X-Request-ID"); id != "" {
+			kv = append(kv, "request_id", id)
+		}
+		logKV(level, r.Method+" "+r.URL.Path, kv)
+	})
+}
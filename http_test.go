@@ -0,0 +1,47 @@
+package slogan
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Linfo)
+	SetSinceFunc(func(time.Time) time.Duration { return 25 * time.Millisecond })
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	r := httptest.NewRequest("GET", "/fail", nil)
+	r.Header.Set("X-Request-ID", "abc")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/ok", nil))
+	ls := lines(b)
+	if len(ls) != 2 {
+		t.Fatalf("want 2 access logs, got %q", b.String())
+	}
+	if got := strings.TrimSpace(ls[0]); got != "error     GET /fail status=500 duration=25.0ms request_id=abc" {
+		t.Errorf("5xx should be logged as error with status and latency, got %q", got)
+	}
+	if got := strings.TrimSpace(ls[1]); got != "info      POST /ok status=200 duration=25.0ms" {
+		t.Errorf("unexpected access log %q", got)
+	}
+}
+
+func TestHTTPMiddlewareCaller(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Linfo)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lsilent)
+	HTTPMiddleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if got := b.String(); !strings.Contains(got, "server.go:") {
+		t.Errorf("caller should be net/http server code, as documented, got %q", got)
+	}
+}
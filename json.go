@@ -0,0 +1,149 @@
+package slogan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// OpenTelemetry severity number and text per level
+var otelSeverities = map[int]struct {
+	number int
+	text   string
+}{
+	Lemergency: {21, "FATAL"},
+	Lalert:     {19, "ERROR3"},
+	Lcritical:  {18, "ERROR2"},
+	Lerror:     {17, "ERROR"},
+	Lwarning:   {13, "WARN"},
+	Lnotice:    {10, "INFO2"},
+	Linfo:      {9, "INFO"},
+	Ldebug:     {5, "DEBUG"},
+	Ltrace:     {1, "TRACE"},
+	Laudit:     {12, "INFO4"},
+}
+
+// Map a level onto OpenTelemetry severity number (1-24, 0 if unspecified) and text
+func OTelSeverityOf(level int) (int, string) {
+	s, ok := otelSeverities[level]
+	if !ok {
+		return 0, ""
+	}
+	return s.number, s.text
+}
+
+// Log entry as JSON object
+type jsonEntry struct {
+	Time           string `json:"time"`
+	Level          string `json:"level"`
+	SeverityNumber int    `json:"severity_number,omitempty"`
+	SeverityText   string `json:"severity_text,omitempty"`
+	Seq            uint64 `json:"seq,omitempty"`
+	Prefix         string `json:"prefix,omitempty"`
+	Host           string `json:"host,omitempty"`
+	PID            int    `json:"pid,omitempty"`
+	Goroutine      uint64 `json:"goroutine,omitempty"`
+	Caller         string `json:"caller,omitempty"`
+	Msg            string `json:"msg"`
+}
+
+// JSON formatter, fields being appended as keys of entry object
+func jsonfmt(level int, log string, fn_ string, line int, r record) string {
+	b, _ := json.Marshal(structured(level, log, fn_, line, r))
+	if len(r.fields) == 0 {
+		return string(b)
+	}
+	b = b[:len(b)-1]
+	for _, f := range r.fields {
+		k, _ := json.Marshal(f.key)
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			v, _ = json.Marshal(fmt.Sprint(f.value))
+		}
+		b = append(append(append(append(b, ','), k...), ':'), v...)
+	}
+	return string(append(b, '}'))
+}
+
+// logfmt formatter, i.e space separated key=value pairs
+func kvfmt(level int, log string, fn_ string, line int, r record) string {
+	e := structured(level, log, fn_, line, r)
+	var b strings.Builder
+	pair := func(k string, v string) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		if v == "" || strings.ContainsAny(v, " =\"\\") || strings.IndexFunc(v, unicode.IsControl) >= 0 {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(k + "=" + v)
+	}
+	pair("time", e.Time)
+	pair("level", e.Level)
+	if e.SeverityNumber != 0 {
+		pair("severity_number", strconv.Itoa(e.SeverityNumber))
+		pair("severity_text", e.SeverityText)
+	}
+	if e.Seq != 0 {
+		pair("seq", strconv.FormatUint(e.Seq, 10))
+	}
+	if e.Prefix != "" {
+		pair("prefix", e.Prefix)
+	}
+	if e.Host != "" {
+		pair("host", e.Host)
+	}
+	if e.PID != 0 {
+		pair("pid", strconv.Itoa(e.PID))
+	}
+	if e.Goroutine != 0 {
+		pair("goroutine", strconv.FormatUint(e.Goroutine, 10))
+	}
+	if e.Caller != "" {
+		pair("caller", e.Caller)
+	}
+	pair("msg", e.Msg)
+	for _, f := range r.fields {
+		pair(f.key, fmt.Sprint(f.value))
+	}
+	return b.String()
+}
+
+// Structured log entry, for JSON and logfmt formatters. Message is without fields.
+func structured(level int, log string, fn_ string, line int, r record) jsonEntry {
+	if r.msg != "" || len(r.fields) > 0 {
+		log = r.msg
+	}
+	if level == Lwarning && WarningAsError == true {
+		level = Lerror
+	}
+	e := jsonEntry{
+		Time:   nowFunc().Format(time.RFC3339Nano),
+		Level:  strings.TrimSpace(tagOf(level)),
+		Prefix: strings.TrimSpace(entryPrefix),
+		Msg:    levelPrefix(level) + log,
+	}
+	if OTelSeverity == true {
+		e.SeverityNumber, e.SeverityText = OTelSeverityOf(level)
+	}
+	if ShowSeq == true {
+		e.Seq = entrySeq
+	}
+	if ShowHost == true {
+		e.Host = getHostname()
+	}
+	if ShowPID == true {
+		e.PID = os.Getpid()
+	}
+	if ShowGoroutine == true {
+		e.Goroutine = goroutineID()
+	}
+	if fn_ != "" {
+		e.Caller = callerWhere(fn_, line)
+	}
+	return e
+}
@@ -0,0 +1,76 @@
+package slogan
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Decode a JSON log entry
+func decode(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var e map[string]interface{}
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatalf("%v : %q", err, data)
+	}
+	return e
+}
+
+func TestOTelSeverity(t *testing.T) {
+	b := setup(t)
+	SetJSON(true)
+	SetOTelSeverity(true)
+	Error("boom")
+	e := decode(t, b.Bytes())
+	if e["severity_number"] != 17.0 || e["severity_text"] != "ERROR" {
+		t.Errorf("want OTel severity 17 ERROR, got %q", b.String())
+	}
+	b.Reset()
+	SetJSON(false)
+	Error("boom")
+	if b.String() != "   error     boom\n" {
+		t.Errorf("OTel severity should only affect JSON, got %q", b.String())
+	}
+}
+
+func TestFormatOutputs(t *testing.T) {
+	setup(t)
+	SetForceColor(true)
+	var term, file, kv bytes.Buffer
+	AddFormatOutput(&term, "human")
+	AddFormatOutput(&file, "json")
+	AddFormatOutput(&kv, "logfmt")
+	Error("boom", "code", 42)
+	if !strings.Contains(term.String(), "\x1b[") || !strings.Contains(term.String(), "boom code=42") {
+		t.Errorf("terminal should get colored text, got %q", term.String())
+	}
+	e := decode(t, file.Bytes())
+	if e["msg"] != "boom" || e["level"] != "error" || e["code"] != 42.0 {
+		t.Errorf("file should get a JSON line with fields as keys, got %q", file.String())
+	}
+	if got := kv.String(); !strings.HasSuffix(got, " level=error msg=boom code=42\n") {
+		t.Errorf("unexpected logfmt line %q", got)
+	}
+	if err := AddFormatOutput(&kv, "xml"); err == nil {
+		t.Errorf("unknown format should be an error")
+	}
+}
+
+func TestJSONSeq(t *testing.T) {
+	b := setup(t)
+	SetJSON(true)
+	SetShowSeq(true)
+	ResetSeq()
+	Error("first")
+	Error("second")
+	ls := lines(b)
+	if len(ls) != 2 {
+		t.Fatalf("want 2 entries, got %q", b.String())
+	}
+	for i, l := range ls {
+		if got := decode(t, []byte(l))["seq"]; got != float64(i+1) {
+			t.Errorf("entry %d: want seq %d, got %v", i, i+1, got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package slogan
+
+import (
+	"fmt"
+)
+
+// Logging methods, so that packages can depend on an interface and inject a mock in tests
+type Interface interface {
+	Emergency(log string, kv ...interface{})
+	Alert(log string, kv ...interface{})
+	Critical(log string, kv ...interface{})
+	Error(log string, kv ...interface{})
+	Warning(log string, kv ...interface{})
+	Notice(log string, kv ...interface{})
+	Info(log string, kv ...interface{})
+	Debug(log string, kv ...interface{})
+	Emergencyf(format string, v ...interface{})
+	Alertf(format string, v ...interface{})
+	Criticalf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+	Warningf(format string, v ...interface{})
+	Noticef(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Debugf(format string, v ...interface{})
+}
+
+// Implementation of Interface backed by package configuration and outputs
+type Logger struct{}
+
+// Default logger, i.e package functions as Interface
+var std = &Logger{}
+
+// Return the logger backed by package functions
+func Default() Interface {
+	return std
+}
+
+// Methods mirror level functions, i.e call logKV, so that caller reported is the one of the method
+
+// Emergency log
+func (l *Logger) Emergency(log string, kv ...interface{}) {
+	logKV(Lemergency, log, kv)
+}
+
+// Alert log
+func (l *Logger) Alert(log string, kv ...interface{}) {
+	logKV(Lalert, log, kv)
+}
+
+// Critical log
+func (l *Logger) Critical(log string, kv ...interface{}) {
+	logKV(Lcritical, log, kv)
+}
+
+// Error log
+func (l *Logger) Error(log string, kv ...interface{}) {
+	logKV(Lerror, log, kv)
+}
+
+// Warning log
+func (l *Logger) Warning(log string, kv ...interface{}) {
+	logKV(Lwarning, log, kv)
+}
+
+// Notice log
+func (l *Logger) Notice(log string, kv ...interface{}) {
+	logKV(Lnotice, log, kv)
+}
+
+// Info log
+func (l *Logger) Info(log string, kv ...interface{}) {
+	logKV(Linfo, log, kv)
+}
+
+// Emergency log, formatted as fmt.Sprintf
+func (l *Logger) Emergencyf(format string, v ...interface{}) {
+	Log(Lemergency, fmt.Sprintf(format, v...))
+}
+
+// Alert log, formatted as fmt.Sprintf
+func (l *Logger) Alertf(format string, v ...interface{}) {
+	Log(Lalert, fmt.Sprintf(format, v...))
+}
+
+// Critical log, formatted as fmt.Sprintf
+func (l *Logger) Criticalf(format string, v ...interface{}) {
+	Log(Lcritical, fmt.Sprintf(format, v...))
+}
+
+// Error log, formatted as fmt.Sprintf
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	Log(Lerror, fmt.Sprintf(format, v...))
+}
+
+// Warning log, formatted as fmt.Sprintf
+func (l *Logger) Warningf(format string, v ...interface{}) {
+	Log(Lwarning, fmt.Sprintf(format, v...))
+}
+
+// Notice log, formatted as fmt.Sprintf
+func (l *Logger) Noticef(format string, v ...interface{}) {
+	Log(Lnotice, fmt.Sprintf(format, v...))
+}
+
+// Info log, formatted as fmt.Sprintf
+func (l *Logger) Infof(format string, v ...interface{}) {
+	Log(Linfo, fmt.Sprintf(format, v...))
+}
+
+// Debug and Debugf methods are in debug.go (no-op versions in nodebug.go)
@@ -0,0 +1,76 @@
+package slogan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Mock of Interface recording calls
+type mockLogger struct {
+	calls []string
+}
+
+func (m *mockLogger) record(level string, msg string) {
+	m.calls = append(m.calls, level+" "+msg)
+}
+
+func (m *mockLogger) Emergency(log string, kv ...interface{}) { m.record("emergency", log) }
+func (m *mockLogger) Alert(log string, kv ...interface{})     { m.record("alert", log) }
+func (m *mockLogger) Critical(log string, kv ...interface{})  { m.record("critical", log) }
+func (m *mockLogger) Error(log string, kv ...interface{})     { m.record("error", log) }
+func (m *mockLogger) Warning(log string, kv ...interface{})   { m.record("warning", log) }
+func (m *mockLogger) Notice(log string, kv ...interface{})    { m.record("notice", log) }
+func (m *mockLogger) Info(log string, kv ...interface{})      { m.record("info", log) }
+func (m *mockLogger) Debug(log string, kv ...interface{})     { m.record("debug", log) }
+func (m *mockLogger) Emergencyf(format string, v ...interface{}) {
+	m.record("emergency", fmt.Sprintf(format, v...))
+}
+func (m *mockLogger) Alertf(format string, v ...interface{}) {
+	m.record("alert", fmt.Sprintf(format, v...))
+}
+func (m *mockLogger) Criticalf(format string, v ...interface{}) {
+	m.record("critical", fmt.Sprintf(format, v...))
+}
+func (m *mockLogger) Errorf(format string, v ...interface{}) {
+	m.record("error", fmt.Sprintf(format, v...))
+}
+func (m *mockLogger) Warningf(format string, v ...interface{}) {
+	m.record("warning", fmt.Sprintf(format, v...))
+}
+func (m *mockLogger) Noticef(format string, v ...interface{}) {
+	m.record("notice", fmt.Sprintf(format, v...))
+}
+func (m *mockLogger) Infof(format string, v ...interface{}) {
+	m.record("info", fmt.Sprintf(format, v...))
+}
+func (m *mockLogger) Debugf(format string, v ...interface{}) {
+	m.record("debug", fmt.Sprintf(format, v...))
+}
+
+// Code under test, depending on Interface
+func fetch(l Interface, url string) {
+	l.Infof("fetching %s", url)
+	l.Error("fetch failed", "url", url)
+}
+
+func TestInterfaceMock(t *testing.T) {
+	m := &mockLogger{}
+	fetch(m, "http://example.com")
+	want := []string{"info fetching http://example.com", "error fetch failed"}
+	if fmt.Sprint(m.calls) != fmt.Sprint(want) {
+		t.Errorf("want calls %q, got %q", want, m.calls)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Linfo)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lerror)
+	fetch(Default(), "http://example.com")
+	ls := lines(b)
+	if len(ls) != 2 || strings.TrimSpace(ls[0]) != "info      fetching http://example.com" || !strings.Contains(ls[1], "logger_test.go:") || !strings.HasSuffix(ls[1], "fetch failed url=http://example.com") {
+		t.Errorf("default logger should log through package, caller being method caller, got %q", b.String())
+	}
+}
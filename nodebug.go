@@ -0,0 +1,32 @@
+//go:build nodebug
+
+package slogan
+
+// Built with "-tags nodebug" : debug and trace logs are no-ops,
+// empty bodies being inlined by the compiler.
+
+// Debug log (disabled)
+func Debug(log string, kv ...interface{}) {}
+
+// Debug log (disabled)
+func (l *Logger) Debug(log string, kv ...interface{}) {}
+
+// Debug log, formatted as fmt.Sprintf (disabled)
+func (l *Logger) Debugf(format string, v ...interface{}) {}
+
+// Debug log computed lazily (disabled, fn is never called)
+func DebugFunc(fn func() string) {}
+
+// Trace log (disabled)
+func Trace(trace interface{}) {}
+
+// Trace log on a single compact line (disabled)
+func TraceLine(trace interface{}) {}
+
+// Trace log with chosen representations only (disabled, verbs are still checked)
+func TraceVerbs(trace interface{}, verbs ...string) error {
+	return checkVerbs(verbs)
+}
+
+// Trace log with caller punctually (disabled)
+func TraceCall(trace interface{}) {}
@@ -0,0 +1,24 @@
+//go:build nodebug
+
+package slogan
+
+import "testing"
+
+func TestNoDebug(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Ltrace)
+	Debug("details")
+	Default().Debugf("%d", 1)
+	DebugFunc(func() string {
+		t.Errorf("message should not be computed")
+		return ""
+	})
+	Trace([]int{1, 2})
+	TraceLine(1)
+	if err := TraceVerbs(1, "%T"); err != nil {
+		t.Error(err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("debug and trace should be no-ops with nodebug tag, got %q", b.String())
+	}
+}
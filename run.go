@@ -0,0 +1,27 @@
+package slogan
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Run a command and log it : command line at debug, each line of stdout at info and of stderr
+// at warning. Exit status is logged at debug on success, at warning otherwise, the error being returned.
+func RunLogged(name string, args ...string) error {
+	Log(Ldebug, fmt.Sprintf(formats["run"], strings.Join(append([]string{name}, args...), " ")))
+	stdout := &prefixWriter{level: Linfo, plain: true}
+	stderr := &prefixWriter{level: Lwarning, plain: true}
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	stdout.Close()
+	stderr.Close()
+	if err != nil {
+		Log(Lwarning, fmt.Sprintf(formats["exit"], name, err))
+		return fmt.Errorf("slogan: %s: %w", name, err)
+	}
+	Log(Ldebug, fmt.Sprintf(formats["exit"], name, "exit status 0"))
+	return nil
+}
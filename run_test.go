@@ -0,0 +1,43 @@
+package slogan
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Not a real test : subprocess of TestRunLogged, writing to stdout and stderr then exiting with given code
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("SLOGAN_HELPER_PROCESS") != "1" {
+		return
+	}
+	fmt.Println("out line")
+	fmt.Fprintln(os.Stderr, "err line")
+	if len(os.Args) > 0 && os.Args[len(os.Args)-1] == "fail" {
+		os.Exit(3)
+	}
+	os.Exit(0)
+}
+
+func TestRunLogged(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Ldebug)
+	t.Setenv("SLOGAN_HELPER_PROCESS", "1")
+	if err := RunLogged(os.Args[0], "-test.run=TestHelperProcess", "--", "ok"); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{"debug     ", "info      out line", "warning   err line", "exit status 0"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in %q", want, got)
+		}
+	}
+	b.Reset()
+	if err := RunLogged(os.Args[0], "-test.run=TestHelperProcess", "--", "fail"); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("non-zero exit should be an error, got %v", err)
+	}
+	if !strings.Contains(b.String(), "warning") || !strings.Contains(b.String(), "exit status 3") {
+		t.Errorf("exit status should be logged as warning, got %q", b.String())
+	}
+}
@@ -0,0 +1,87 @@
+package slogan
+
+import (
+	"context"
+	"log/slog"
+)
+
+// log/slog Handler backed by slogan
+type slogHandler struct {
+	attrs  []field // attributes of WithAttrs, groups flattened
+	prefix string  // current group prefix of attribute keys
+}
+
+// Return a log/slog Handler routing records through slogan, i.e slog.New(slogan.SlogHandler())
+func SlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+// Map a slog level to a slogan level
+func slogLevel(l slog.Level) int {
+	switch {
+	case l < slog.LevelDebug:
+		return Ltrace
+	case l < slog.LevelInfo:
+		return Ldebug
+	case l < slog.LevelWarn:
+		return Linfo
+	case l < slog.LevelError:
+		return Lwarning
+	case l == slog.LevelError:
+		return Lerror
+	default:
+		return Lcritical
+	}
+}
+
+// Whether records of this level would be logged
+func (h *slogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return enabled(effectiveLevel(slogLevel(l)))
+}
+
+// Log a record, attributes being carried as fields
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := append([]field(nil), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	// caller is above slog.Logger methods
+	emit(slogLevel(r.Level), r.Message, record{skip: 1, fields: fields})
+	return nil
+}
+
+// Return a new handler with given attributes
+func (h *slogHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	fields := append([]field(nil), h.attrs...)
+	for _, a := range as {
+		fields = appendAttr(fields, h.prefix, a)
+	}
+	return &slogHandler{attrs: fields, prefix: h.prefix}
+}
+
+// Return a new handler qualifying next attribute keys with group name
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// Append an attribute as field(s), groups being flattened with dotted keys
+func appendAttr(fields []field, prefix string, a slog.Attr) []field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, g := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, g)
+		}
+		return fields
+	}
+	return append(fields, field{prefix + a.Key, a.Value.Any()})
+}
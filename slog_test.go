@@ -0,0 +1,51 @@
+package slogan
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Linfo)
+	logger := slog.New(SlogHandler())
+	logger.Info("request", "method", "GET", slog.Group("user", "id", 42))
+	if got := strings.TrimSpace(b.String()); got != "info      request method=GET user.id=42" {
+		t.Errorf("unexpected entry %q", got)
+	}
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Errorf("debug should not be enabled at info verbosity")
+	}
+	b.Reset()
+	logger.Debug("hidden")
+	if b.Len() != 0 {
+		t.Errorf("debug should be gated, got %q", b.String())
+	}
+}
+
+func TestSlogHandlerJSON(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Linfo)
+	SetJSON(true)
+	slog.New(SlogHandler()).With("a", 1).WithGroup("g").Warn("hi", "k", "v")
+	var e map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &e); err != nil {
+		t.Fatalf("%v : %q", err, b.String())
+	}
+	if e["msg"] != "hi" || e["level"] != "warning" || e["a"] != 1.0 || e["g.k"] != "v" {
+		t.Errorf("attributes should be JSON keys, got %q", b.String())
+	}
+}
+
+func TestSlogHandlerCaller(t *testing.T) {
+	b := setup(t)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lsilent)
+	slog.New(SlogHandler()).Error("boom")
+	if !strings.Contains(b.String(), "slog_test.go:") {
+		t.Errorf("caller should be above slog.Logger methods, got %q", b.String())
+	}
+}
@@ -2,6 +2,8 @@
 package slogan
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/bclicn/color" // colorize output
 	"golang.org/x/crypto/ssh/terminal"
@@ -9,10 +11,17 @@ import (
 	"log"
 	"os"
 	"path"
+	"regexp"
 	"runtime"
-	"syscall"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
-	"unsafe"
+	"unicode"
+	"unicode/utf8"
 )
 
 /*
@@ -33,6 +42,7 @@ const (
 	Linfo      = 7
 	Ldebug     = 8
 	Ltrace     = 9
+	Laudit     = 11 // audit messages, always emitted whatever verbosity
 )
 
 // Contants for legacy log package
@@ -49,9 +59,59 @@ const (
 // Default legacy logger on stderr
 var logger = log.New(os.Stderr, "", 0)
 
+// Current output of logger
+var output io.Writer = os.Stderr
+
+// Dedicated legacy logger for audit messages, if any
+var auditLogger *log.Logger
+var auditOutput io.Writer
+var auditTerminal bool
+
+// Options of a log entry, passed along its emission
+type record struct {
+	noNewline bool    // see LogNoNewline
+	skip      int     // stack frames to skip for caller, relative to level functions
+	color     string  // color of tag and message overriding colors map, see ColorLog
+	fields    []field // key/value fields, appended to message in text
+	msg       string  // message without fields, as written
+}
+
+// Key/value field of a log entry
+type field struct {
+	key   string
+	value interface{}
+}
+
+// Custom sink replacing outputs, if any
+var sink func(level int, rendered string)
+
+// Dynamic prefix, if any
+var prefixFunc func() string
+
+// Prefix of entry being written, outputsMu must be locked
+var entryPrefix string
+
+// Sequence number of last entry, and of entry being written (outputsMu must be locked)
+var seq uint64
+var entrySeq uint64
+
+// Time of last entry, and delta of entry being written since it (outputsMu must be locked)
+var lastEntry time.Time
+var entryDelta time.Duration
+
+// Additional outputs
+var outputs TeeWriter
+var outputsMu sync.Mutex
+
 // Check if stderr is a terminal
 var isTerminal = terminal.IsTerminal(int(os.Stderr.Fd()))
 
+// Wall clock of timestamps, quiet hours and throttling, can be replaced for tests
+var nowFunc = time.Now
+
+// Stopwatch of durations, monotonic by default, can be replaced for tests
+var sinceFunc = time.Since
+
 // Start time reference
 var start = time.Now()
 // Last time reference
@@ -72,23 +132,90 @@ var tags = [10]string{
 	"trace    ", // 9
 }
 
+// compact tags map, used if CompactTags=true.
+// An empty entry means first letter of tag, uppercased.
+var compactTags = [10]string{}
+
+// emoji map per log level, prepended to tags if Emoji=true.
+// index 0 is unused
+var emojis = [10]string{
+	"",   // Silent
+	"🔥",  // 1
+	"🚨",  // 2
+	"💥",  // 3
+	"❌",  // 4
+	"⚠️", // 5
+	"📣",  // 6
+	"ℹ️", // 7
+	"🐞",  // 8
+	"🔍",  // 9
+}
+
+// tag of audit messages
+var auditTag = "audit    "
+
+// Custom level registered with RegisterLevel
+type customLevel struct {
+	tag   string
+	color string
+}
+
+// custom levels, above trace
+var customLevels = map[int]customLevel{}
+var customLevelsMu sync.RWMutex
+
 // log formats map
 var formats = map[string]string{
-	"fatal":   "Immediate exit with code %d", // immediate exit on error format
-	"trace":   "%[1]T\n %%v: %[1]v\n\n%%v+: %+[1]v\n\n%%#v: %#[1]v",
-	"empty":   "%#v",
-	"runtime": "OS:%s ARCH:%s CPU:%d COMPILER:%s ROOT:%s",
-	"default": "   %[1]s %[2]s",
-	"caller":  "   %[1]s %[3]s\t %[2]s",
-	"where":   "%s:%d",
-	"alldone": "All done in : %s",
-	"elapsed": "Elapsed time : %s",
+	"fatal":      "Immediate exit with code %d", // immediate exit on error format
+	"trace":      "%[1]T\n %%v: %[1]v\n\n%%v+: %+[1]v\n\n%%#v: %#[1]v",
+	"empty":      "%#v",
+	"traceline":  "type=%[1]T value=%+[1]v",
+	"traceverb":  "%s: %s",
+	"runtime":    "OS:%s ARCH:%s CPU:%d COMPILER:%s ROOT:%s",
+	"memstats":   "ALLOC:%s TOTALALLOC:%s SYS:%s NUMGC:%d",
+	"default":    "   %[1]s %[2]s",
+	"caller":     "   %[1]s %[3]s\t %[2]s",
+	"layoutsep":  " ",
+	"layouttime": "15:04:05.000",
+	"where":      "%s:%d",
+	"assert":     "assertion failed: %s (%s)",
+	"alldone":    "All done in : %s",
+	"elapsed":    "Elapsed time : %s",
+	"timed":      "%s took %s",
+	"clamped":    "verbosity %s clamped to %s by SLOGAN_MAX_LEVEL",
+	"timedslow":  "%s took %s, more than %s",
+	"goroutine":  "[%d] ",
+	"host":       "%s ",
+	"pid":        "[%d] ",
+	"truncated":  "…(truncated)",
+	"throttled":  " (%d suppressed in last %s)",
+	"field":      " %s=%v",
+	"since":      "[+%.3fs] ",
+	"delta":      "+%.3fs ",
+	"bullet":     "  - %s",
+	"summary":    "%s: %d",
+	"run":        "$ %s",
+	"exit":       "%s: %v",
+	"spinner":    "   %s %s",
+	"spinok":     "%s : done",
+	"spinfail":   "%s : failed",
+	"hexdump":    "%08x  %s |%s|",
+	"niloutput":  "Ignoring nil output, current one is kept",
+	"keyvalue":   "%s : %s",
+	"seq":        "#%d ",
 }
 
 // colors map.
 // index 0 is for log prefix.
 // index 10 is for caller.
+// index 11 is for audit.
+// index 12 and 13 are for numbers and quoted strings if SyntaxHighlight=true.
 var colors = map[int]string{
+	15: "Red",
+	14: "Green",
+	13: "Green",
+	12: "Cyan",
+	11: "BCyan",
 	10: "Underline",
 	9:  "DarkGray",
 	8:  "DarkGray",
@@ -102,6 +229,82 @@ var colors = map[int]string{
 	0:  "",
 }
 
+// color themes presets
+var themes = map[string]map[int]string{
+	"dark": {
+		15: "LightRed",
+		14: "LightGreen",
+		13: "LightGreen",
+		12: "LightCyan",
+		11: "BLightCyan",
+		10: "Underline",
+		9:  "DarkGray",
+		8:  "LightGray",
+		7:  "LightCyan",
+		6:  "LightGreen",
+		5:  "LightYellow",
+		4:  "LightRed",
+		3:  "BLightRed",
+		2:  "BLightPurple",
+		1:  "GRed",
+		0:  "",
+	},
+	"light": {
+		15: "Red",
+		14: "Green",
+		13: "Green",
+		12: "Blue",
+		11: "BBlue",
+		10: "Underline",
+		9:  "DarkGray",
+		8:  "DarkGray",
+		7:  "Blue",
+		6:  "Green",
+		5:  "BPurple",
+		4:  "Red",
+		3:  "BRed",
+		2:  "BRed",
+		1:  "GRed",
+		0:  "",
+	},
+	"monochrome": {
+		15: "Dim",
+		14: "Bold",
+		13: "Underline",
+		12: "Bold",
+		11: "Bold",
+		10: "Underline",
+		9:  "Dim",
+		8:  "Dim",
+		7:  "",
+		6:  "",
+		5:  "Bold",
+		4:  "Bold",
+		3:  "Bold",
+		2:  "Invert",
+		1:  "Invert",
+		0:  "",
+	},
+	"solarized": {
+		15: "Red",
+		14: "Green",
+		13: "Green",
+		12: "Cyan",
+		11: "BCyan",
+		10: "Underline",
+		9:  "DarkGray",
+		8:  "Cyan",
+		7:  "Blue",
+		6:  "Green",
+		5:  "Yellow",
+		4:  "Red",
+		3:  "BRed",
+		2:  "BPurple",
+		1:  "GPurple",
+		0:  "",
+	},
+}
+
 // parts map.
 // What parts of log should be colorized if Colorize=true
 var parts = map[string]bool{
@@ -114,28 +317,180 @@ var parts = map[string]bool{
 // offset for stack depth
 var offset = 0
 
-// verbosity
-var Verbosity int = Lwarning
+// Fields available in template formats, e.g "{{.Tag}} {{.Caller}} {{.Msg}}"
+type TemplateData struct {
+	Tag    string
+	Msg    string
+	Caller string
+}
+
+// parsed template formats cache
+var templates = map[string]*template.Template{}
+var templatesMu sync.Mutex
+
+// quiet hours window of a level, in minutes since midnight
+type quietHours struct {
+	from       int
+	to         int
+	suppressed int
+}
+
+// quiet hours per level
+var quiets = map[int]*quietHours{}
+var quietsMu sync.Mutex
+
+// patterns of messages to drop, and count of dropped messages
+var drops []*regexp.Regexp
+var dropped int
+var dropsMu sync.Mutex
+
+// substrings of files never reported as caller
+var callerIgnores []string
+var callerIgnoresMu sync.RWMutex
+
+// prefixes of log messages per level
+var levelPrefixes = map[int]string{}
+var levelPrefixesMu sync.RWMutex
+
+// throttle state of a level
+type throttle struct {
+	interval   time.Duration
+	last       time.Time
+	suppressed int
+}
+
+// throttles per level
+var throttles = map[int]*throttle{}
+var throttlesMu sync.Mutex
+
+// maximum severity of logs, i.e lowest level (Lsilent means no maximum), read and written atomically
+var maxLevel int32 = Lsilent
+
+// level remap.
+// Incoming level (key) is translated to another level (value) before any processing
+var remaps = map[int]int{}
+var remapsMu sync.RWMutex
+
+// verbosity, read and written atomically
+var verbosity int32 = Lwarning
+
 // should exit on error ?
 var ExitOnError bool = false
+// function called for immediate exit, can be replaced for tests
+var ExitFunc func(code int) = os.Exit
 // should warning be error ?
 var WarningAsError bool = false
 // should trace caller ?
 var TraceCaller bool = false
+// number of trailing path segments of caller to show (0 means CallerBase rules)
+var CallerDepth int = 0
+// least severe level showing caller, if TraceCaller=true (Lsilent means all levels)
+var CallerMinLevel int = Lsilent
+// should show package qualified function of caller instead of file ?
+var CallerPackage bool = false
+// should skip runtime and standard library frames for caller ?
+var CallerSkipRuntime bool = false
 // should show only basename of caller
 var CallerBase bool = true
 // should colorize ?
 var Colorize bool = true
 // should colorize even if output is not a terminal ?
 var ForceColorize bool = false 
+// should Hide and Blink fall back to Bold on terminals not supporting them ?
+var EffectFallback bool = true
 // should empty log string logged ?
 var NoEmpty bool = false
+// should highlight numbers and quoted strings in messages ?
+var SyntaxHighlight bool = false
+// should use single character tags ?
+var CompactTags bool = false
+// should colorize diff lines of messages ?
+var DiffColors bool = false
+// should silent messages be delivered to subscribers ?
+var SilentToHooks bool = false
+// should prepend emoji to tags ?
+var Emoji bool = false
+// should show elapsed time since start ?
+var ShowElapsed bool = false
+// should log as JSON objects ?
+var JSON bool = false
+// should add OpenTelemetry severity fields in JSON ?
+var OTelSeverity bool = false
+// should show sequence number of entries ?
+var ShowSeq bool = false
+// should show time since previous entry ?
+var ShowDelta bool = false
+// should escape control characters of messages ?
+var Sanitize bool = false
+// should show goroutine ID ?
+var ShowGoroutine bool = false
+// should show hostname ?
+var ShowHost bool = false
+// should show process ID ?
+var ShowPID bool = false
+// levels to show in addition to verbosity, bit i for level i (0 means all)
+var LevelMask uint = 0
+// maximum bytes of log message (0 means no limit)
+var MaxMessageBytes int = 0
+// should continuation lines of messages be aligned under first line ?
+var AlignMultiline bool = false
+// should Flush sync outputs to disk ?
+var SyncOnFlush bool = false
 
 //************ Exported functions for configuration *************
 
-/* Set global verbosity */
+// Set global verbosity, safe for concurrent use.
+// If SLOGAN_MAX_LEVEL environment variable is set (level name or number), verbosity is capped to it, with a one-time notice.
 func SetVerbosity(level int) {
-	Verbosity = level
+	if max, ok := envMaxLevel(); ok && level > max {
+		clampOnce.Do(func() {
+			clamped := fmt.Sprintf(formats["clamped"], levelName(level), levelName(max))
+			write(Lnotice, clamped, record{msg: clamped})
+		})
+		level = max
+	}
+	atomic.StoreInt32(&verbosity, int32(level))
+}
+
+// Notice of verbosity clamped by SLOGAN_MAX_LEVEL, shown once
+var clampOnce sync.Once
+
+// Maximum verbosity from SLOGAN_MAX_LEVEL environment variable, if set and valid
+func envMaxLevel() (int, bool) {
+	v := os.Getenv("SLOGAN_MAX_LEVEL")
+	if v == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return n, true
+	}
+	return levelFromName(v)
+}
+
+/* Get global verbosity, safe for concurrent use */
+func GetVerbosity() int {
+	return int(atomic.LoadInt32(&verbosity))
+}
+
+// Set verbosity temporarily and return a function restoring former verbosity.
+// Intended usage : defer slogan.TempVerbosity(slogan.Ltrace)()
+// Verbosity is global : other goroutines are affected until restore.
+func TempVerbosity(level int) func() {
+	old := GetVerbosity()
+	SetVerbosity(level)
+	return func() {
+		SetVerbosity(old)
+	}
+}
+
+// Cap severity of all following logs to max until returned function is called,
+// e.g an error is logged as debug with WithMaxLevel(Ldebug). Silent and audit logs are not affected.
+// Intended usage : defer slogan.WithMaxLevel(slogan.Ldebug)()
+func WithMaxLevel(max int) func() {
+	old := atomic.SwapInt32(&maxLevel, int32(max))
+	return func() {
+		atomic.StoreInt32(&maxLevel, old)
+	}
 }
 
 /* Set exit on level error or higher */
@@ -143,6 +498,11 @@ func SetExitOnError(mode bool) {
 	ExitOnError = mode
 }
 
+/* Set format of message logged before immediate exit, receiving exit code */
+func SetExitMessage(format string) {
+	formats["fatal"] = format
+}
+
 /* Set warning as error */
 func SetWarningAsError(mode bool) {
 	WarningAsError = mode
@@ -153,11 +513,63 @@ func SetTraceCaller(mode bool) {
 	TraceCaller = mode
 }
 
+/* Show n last path segments of caller, e.g 2 for "pkg/file.go". 0 restores default (see SetFlags) */
+func SetCallerDepth(n int) {
+	CallerDepth = n
+}
+
+/* Show caller only for messages of level or more severe, e.g Lerror. TraceCaller must be set. Lsilent means all levels. */
+func SetCallerMinLevel(level int) {
+	CallerMinLevel = level
+}
+
+/* Show caller as package qualified function name (e.g "github.com/me/app/db.Open:42") instead of file */
+func SetCallerPackage(mode bool) {
+	CallerPackage = mode
+}
+
+/* Skip Go runtime and standard library frames when looking for caller */
+func SetCallerSkipRuntime(mode bool) {
+	CallerSkipRuntime = mode
+}
+
 /* Colorize or not */
 func SetColor(mode bool) {
 	Colorize = mode
 }
 
+// Set color mode, as git or ls do : "auto" colorizes terminals only, "always" colorizes even if not a terminal,
+// "never" disables color. It sets Colorize and ForceColorize, so that SetColor and SetForceColor still apply.
+func SetColorMode(mode string) error {
+	switch mode {
+	case "auto":
+		Colorize, ForceColorize = true, false
+	case "always":
+		Colorize, ForceColorize = true, true
+	case "never":
+		Colorize, ForceColorize = false, false
+	default:
+		return fmt.Errorf("slogan: unknown color mode %q (auto, always or never)", mode)
+	}
+	return nil
+}
+
+// Current color mode, from Colorize and ForceColorize (see SetColorMode)
+func ColorMode() string {
+	if Colorize == false {
+		return "never"
+	}
+	if ForceColorize == true {
+		return "always"
+	}
+	return "auto"
+}
+
+/* Replace poorly supported Hide and Blink effects by Bold, depending TERM */
+func SetEffectFallback(mode bool) {
+	EffectFallback = mode
+}
+
 /* Force colorization even if not a terminal */
 func SetForceColor(mode bool) {
 	ForceColorize = mode
@@ -168,6 +580,217 @@ func SetNoEmpty(mode bool) {
 	NoEmpty = mode
 }
 
+/* Show elapsed time since start time reference in each log entry */
+func SetShowElapsed(mode bool) {
+	ShowElapsed = mode
+}
+
+/* Log entries as JSON objects, one per line */
+func SetJSON(mode bool) {
+	JSON = mode
+}
+
+/* Add OpenTelemetry severity_number and severity_text fields to JSON entries */
+func SetOTelSeverity(mode bool) {
+	OTelSeverity = mode
+}
+
+/* Show an increasing sequence number in each log entry, for ordering merged logs */
+func SetShowSeq(mode bool) {
+	ShowSeq = mode
+}
+
+/* Restart sequence numbers of log entries at 1 */
+func ResetSeq() {
+	atomic.StoreUint64(&seq, 0)
+}
+
+/* Show duration since previous entry in each log entry, first one showing +0.000s, for spotting slow gaps */
+func SetShowDelta(mode bool) {
+	ShowDelta = mode
+}
+
+/* Escape control characters of messages, e.g "\n" as `\n`, preventing forged entries from untrusted input */
+func SetSanitize(mode bool) {
+	Sanitize = mode
+}
+
+/* Show goroutine ID in each log entry (costly, see goroutineID) */
+func SetShowGoroutine(mode bool) {
+	ShowGoroutine = mode
+}
+
+/* Show hostname in each log entry */
+func SetShowHost(mode bool) {
+	ShowHost = mode
+}
+
+/* Show process ID in each log entry */
+func SetShowPID(mode bool) {
+	ShowPID = mode
+}
+
+/* Set a prefix to messages of a level, e.g "[ERROR] ". An empty prefix removes it. */
+func SetLevelPrefix(level int, prefix string) {
+	levelPrefixesMu.Lock()
+	defer levelPrefixesMu.Unlock()
+	if prefix == "" {
+		delete(levelPrefixes, level)
+		return
+	}
+	levelPrefixes[level] = prefix
+}
+
+// Drop messages of a level logged during a daily window, from and to being local "HH:MM" times.
+// Window may cross midnight (e.g "22:00" to "07:00"). Empty from and to remove quiet hours.
+func SetQuietHours(level int, from string, to string) error {
+	quietsMu.Lock()
+	defer quietsMu.Unlock()
+	if from == "" && to == "" {
+		delete(quiets, level)
+		return nil
+	}
+	f, err := parseHHMM(from)
+	if err != nil {
+		return err
+	}
+	t, err := parseHHMM(to)
+	if err != nil {
+		return err
+	}
+	quiets[level] = &quietHours{from: f, to: t}
+	return nil
+}
+
+/* Count of messages of a level dropped during quiet hours */
+func QuietSuppressed(level int) int {
+	quietsMu.Lock()
+	defer quietsMu.Unlock()
+	if q, ok := quiets[level]; ok {
+		return q.suppressed
+	}
+	return 0
+}
+
+/* Deliver silent messages to subscribers (see Subscribe), while never writing them */
+func SetSilentToHooks(mode bool) {
+	SilentToHooks = mode
+}
+
+/* Drop messages matching a regular expression, e.g health check noise */
+func AddDropPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("slogan: drop pattern: %w", err)
+	}
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+	drops = append(drops, re)
+	return nil
+}
+
+/* Remove all drop patterns */
+func ClearDropPatterns() {
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+	drops = nil
+}
+
+/* Never report as caller a file containing substring, e.g generated code or shims, next frame being reported instead */
+func AddCallerIgnore(substr string) {
+	callerIgnoresMu.Lock()
+	defer callerIgnoresMu.Unlock()
+	callerIgnores = append(callerIgnores, substr)
+}
+
+/* Remove all caller ignored substrings */
+func ClearCallerIgnores() {
+	callerIgnoresMu.Lock()
+	defer callerIgnoresMu.Unlock()
+	callerIgnores = nil
+}
+
+/* Count of messages dropped by drop patterns */
+func DroppedCount() int {
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+	return dropped
+}
+
+/* Set a clock function used instead of time.Now for timestamps, quiet hours and throttling (mainly for tests) */
+func SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	nowFunc = now
+}
+
+// Set a stopwatch function used instead of time.Since for durations since time references
+// (AllDone, ElapsedTime, elapsed field), mainly for tests. Durations are then unaffected by SetClock.
+func SetSinceFunc(since func(t time.Time) time.Duration) {
+	if since == nil {
+		since = time.Since
+	}
+	sinceFunc = since
+}
+
+/* Emit at most one log per interval for level, counting suppressed ones. A zero interval removes throttle. */
+func SetThrottle(level int, interval time.Duration) {
+	throttlesMu.Lock()
+	defer throttlesMu.Unlock()
+	if interval <= 0 {
+		delete(throttles, level)
+		return
+	}
+	throttles[level] = &throttle{interval: interval}
+}
+
+// Show only levels whose bit is set in mask, in addition to verbosity (0 means all levels).
+// Audit messages are not affected. See MaskOf.
+func SetLevelMask(mask uint) {
+	LevelMask = mask
+}
+
+// Level mask of given levels, e.g MaskOf(Lerror, Ldebug)
+func MaskOf(levels ...int) uint {
+	var mask uint
+	for _, l := range levels {
+		mask |= 1 << uint(l)
+	}
+	return mask
+}
+
+/* Truncate log messages longer than n bytes (0 means no limit) */
+func SetMaxMessageBytes(n int) {
+	MaxMessageBytes = n
+}
+
+/* Indent continuation lines of multi-line messages to align them under the first line */
+func SetAlignMultiline(mode bool) {
+	AlignMultiline = mode
+}
+
+// Make Flush call Sync on outputs implementing it (e.g *os.File), so that entries reach the disk.
+// Each sync is a system call waiting for the device : costly if Flush is called often.
+func SetSyncOnFlush(mode bool) {
+	SyncOnFlush = mode
+}
+
+// Remap a level to another one, i.e treat all 'from' logs as 'to' logs.
+// Remaps are applied once, not transitively. Remapping a level to itself removes remap.
+func SetLevelRemap(from int, to int) {
+	if from < Lsilent || from > Ltrace || to < Lsilent || to > Ltrace {
+		return
+	}
+	remapsMu.Lock()
+	defer remapsMu.Unlock()
+	if from == to {
+		delete(remaps, from)
+		return
+	}
+	remaps[from] = to
+}
+
 /* Get color map */
 func GetColors() map[int]string {
 	return colors
@@ -175,7 +798,12 @@ func GetColors() map[int]string {
 
 /* Display color map */
 func ShowColors() {
-	fmt.Printf("%#v\n", colors)
+	FprintColors(os.Stdout)
+}
+
+/* Write color map to w */
+func FprintColors(w io.Writer) {
+	fmt.Fprintf(w, "%#v\n", colors)
 }
 
 /* Set new color map and return former map */
@@ -185,61 +813,269 @@ func SetColors(n map[int]string) map[int]string {
 	return old
 }
 
+// Set color map from a theme preset : "dark", "light", "monochrome" or "solarized",
+// or "auto" for "dark" or "light" depending terminal background (see DetectBackground).
+// An unknown theme, or an undetected background, returns an error and leaves color map unchanged.
+func SetTheme(name string) error {
+	if name == "auto" {
+		bg, ok := DetectBackground()
+		if !ok {
+			return fmt.Errorf("slogan: cannot detect terminal background")
+		}
+		name = bg
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("slogan: unknown theme %q", name)
+	}
+	n := make(map[int]string, len(theme))
+	for k, v := range theme {
+		n[k] = v
+	}
+	colors = n
+	return nil
+}
+
 /* API for logger override */
 func SetFlags(flag int) {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	setFlags(flag)
+}
+
+// Set flags of all legacy loggers, Lshortfile and Llongfile being applied to caller options (outputsMu must be locked)
+func setFlags(flag int) {
 	if (flag & Lshortfile) == Lshortfile {
 		TraceCaller = true
 		CallerBase = true
-		SetFlags(flag - Lshortfile)
+		setFlags(flag - Lshortfile)
 	} else if (flag & Llongfile) == Llongfile {
 		TraceCaller = true
 		CallerBase = false
-		SetFlags(flag - Llongfile)
+		setFlags(flag - Llongfile)
 	} else {
 		logger.SetFlags(flag)
+		for _, o := range outputs.targets() {
+			o.l.SetFlags(flag)
+		}
+		if auditLogger != nil {
+			auditLogger.SetFlags(flag)
+		}
 	}
 }
 
-/* Set a prefix to log entries and return former prefix */
+// Return legacy logger slogan writes through to output, for advanced tuning.
+// Beware that slogan manages its output, flags and prefix : SetOutput, SetFlags and SetPrefix should be preferred.
+func StdlibLogger() *log.Logger {
+	return logger
+}
+
+// Set a prefix to log entries and return former prefix.
+// Prefix is stored in tags[0] and prepended by slogan itself, not by legacy logger.
 func SetPrefix(prefix string) string {
-	defer logger.SetPrefix(prefix)
 	old := tags[0]
 	tags[0] = prefix
 	return old
 }
 
-/* Set an io.Writer to log output */
+// Set a function computing prefix of each log entry, e.g a request ID or a counter.
+// It is called once per log without any package lock held, taking precedence over SetPrefix.
+// A nil function restores static prefix.
+func SetPrefixFunc(f func() string) {
+	prefixFunc = f
+}
+
+// Set a function receiving each rendered entry instead of output and additional outputs,
+// e.g to send logs through gRPC or a message queue. Entries are colorized unless SetColor(false),
+// legacy logger flags (date, time) are not applied. A nil sink restores outputs.
+// Sink is called without any package lock held, so that it may log itself, e.g a transport failure,
+// also in asynchronous mode, entries it logs being then written synchronously by background writer.
+func SetSink(f func(level int, rendered string)) {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	sink = f
+}
+
+/* Set an io.Writer to log output. A nil writer is ignored, with a warning on current output. */
 func SetOutput(w io.Writer) {
-	if w != os.Stderr || w != os.Stdout {
-		isTerminal = false
+	if w == nil {
+		Log(Lwarning, formats["niloutput"])
+		return
 	}
+	isTerminal = isTerminalWriter(w)
+	output = w
 	logger.SetOutput(w)
 }
 
+// Release resources : stop resize watcher and periodic flush, write pending asynchronous entries, close outputs that are io.Closer
+// (stdout and stderr excepted) and fall back to stderr. Intended to be deferred in main.
+func Close() error {
+	unwatchResize()
+	SetAsyncFlushInterval(0)
+	asyncMu.Lock()
+	stopAsync()
+	asyncMu.Unlock()
+	var err error
+	if c, ok := output.(io.Closer); ok && output != os.Stderr && output != os.Stdout {
+		err = c.Close()
+	}
+	if fileOutput != nil && fileOutput != output {
+		// replaced since opened by LoadConfigFile
+		fileOutput.Close()
+	}
+	fileOutput = nil
+	outputsMu.Lock()
+	for _, o := range outputs.clear() {
+		if c, ok := o.w.(io.Closer); ok && o.w != os.Stderr && o.w != os.Stdout {
+			if e := c.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	if c, ok := auditOutput.(io.Closer); ok && auditOutput != os.Stderr && auditOutput != os.Stdout {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	auditOutput = nil
+	auditLogger = nil
+	outputsMu.Unlock()
+	SetOutput(os.Stderr)
+	return err
+}
+
+// Set a dedicated output for audit messages. A nil writer routes them back to regular outputs.
+func SetAuditOutput(w io.Writer) {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	auditOutput = w
+	if w == nil {
+		auditLogger = nil
+		return
+	}
+	auditLogger = log.New(w, "", logger.Flags())
+	auditTerminal = isTerminalWriter(w)
+}
+
+// Register an additional output. Each output is colorized independently :
+// only if it is a terminal (or if ForceColorize=true), so that files get plain text.
+func AddOutput(w io.Writer) {
+	outputs.Add(w, isTerminalWriter(w))
+}
+
+// Register an additional output always colorized, even if not a terminal
+func AddColorOutput(w io.Writer) {
+	outputs.Add(w, true)
+}
+
+// Register an additional output with its own format of entries, whatever JSON option :
+// "human" (colorized only if a terminal), "json" or "logfmt" (key=value pairs).
+// e.g JSON in a file and colors on terminal at the same time.
+func AddFormatOutput(w io.Writer, format string) error {
+	switch format {
+	case "human", "json", "logfmt":
+	default:
+		return fmt.Errorf("slogan: unknown output format %q", format)
+	}
+	outputs.add(w, format == "human" && isTerminalWriter(w), format)
+	return nil
+}
+
+// Unregister an additional output
+func RemoveOutput(w io.Writer) {
+	outputs.Remove(w)
+}
+
 /* Notice Time elapsed since start and reset start time reference */
 func AllDone() {
-	elapsed := time.Since(start)
+	elapsed := sinceFunc(start)
 	defer resetStart()
 	incr_offset()
 	defer decr_offset()
-	Notice(fmt.Sprintf(formats["alldone"], elapsed))
+	Notice(fmt.Sprintf(formats["alldone"], Duration(elapsed)))
 }
 
 /* Notice Time elapsed since last call to this function or since start otherwise and reset time reference */
 func ElapsedTime() {
-	elapsed := time.Since(last)
+	elapsed := sinceFunc(last)
 	defer resetLast()
 	incr_offset()
 	defer decr_offset()
-	Notice(fmt.Sprintf(formats["elapsed"], elapsed))
+	Notice(fmt.Sprintf(formats["elapsed"], Duration(elapsed)))
+}
+
+// Time an operation : returned function, to be deferred, logs elapsed time as debug,
+// or as warning if it exceeds threshold, e.g defer slogan.Timed("query", 100*time.Millisecond)()
+func Timed(name string, threshold time.Duration) func() {
+	begin := time.Now()
+	return func() {
+		elapsed := sinceFunc(begin)
+		if elapsed > threshold {
+			Log(Lwarning, fmt.Sprintf(formats["timedslow"], name, Duration(elapsed), Duration(threshold)))
+			return
+		}
+		Log(Ldebug, fmt.Sprintf(formats["timed"], name, Duration(elapsed)))
+	}
+}
+
+/* Humanized duration, e.g "1.5s", "250.0ms" or "1m30s" */
+func Duration(d time.Duration) string {
+	if d < 0 {
+		return "-" + Duration(-d)
+	}
+	switch {
+	case d < time.Microsecond:
+		return fmt.Sprintf("%dns", int64(d))
+	case d < time.Millisecond:
+		return fmt.Sprintf("%.1fµs", float64(d)/float64(time.Microsecond))
+	case d < time.Second:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+/* Humanized size in binary units, e.g "512 B", "1.0 KiB" or "1.0 MiB" */
+func Bytes(n int64) string {
+	if n < 0 {
+		return "-" + Bytes(-n)
+	}
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	units := "KMGTPE"
+	v := float64(n) / 1024
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f %ciB", v, units[i])
+}
+
+/* Reset start time reference to now, e.g after initialization */
+func ResetStart() {
+	resetStart()
+}
+
+/* Set start time reference */
+func SetStart(t time.Time) {
+	start = t
+}
+
+/* Get start time reference */
+func StartTime() time.Time {
+	return start
 }
 
-/* Reset start time reference */ 
+/* Reset start time reference, on monotonic clock */ 
 func resetStart() {
 	start = time.Now()
 }
 
-/* Reset time reference for ETA */
+/* Reset time reference for ETA, on monotonic clock */
 func resetLast() {
 	last = time.Now()
 }
@@ -253,7 +1089,12 @@ func GetTags() [10]string {
 
 // Display tag map
 func ShowTags() {
-	fmt.Printf("%#v\n", tags)
+	FprintTags(os.Stdout)
+}
+
+// Write tag map to w
+func FprintTags(w io.Writer) {
+	fmt.Fprintf(w, "%#v\n", tags)
 }
 
 // Set a new tag map and return former map
@@ -263,8 +1104,128 @@ func SetTags(n [10]string) [10]string {
 	return old
 }
 
+// Register a custom level above trace, e.g 10 for a "verbose" level, with its tag and color
+// name (i.e "Cyan", see colors map). It is logged with LogCustom and gated by verbosity as other levels.
+func RegisterLevel(level int, name string, color string) error {
+	// indexes of colors map above audit are used for highlights and diffs
+	if level <= Ltrace || (level >= Laudit && level <= 15) {
+		return fmt.Errorf("slogan: level %d is reserved", level)
+	}
+	if color != "" && paint(color, "x") == "x" {
+		return fmt.Errorf("slogan: unknown color %q", color)
+	}
+	customLevelsMu.Lock()
+	defer customLevelsMu.Unlock()
+	customLevels[level] = customLevel{tag: fmt.Sprintf("%-9s", name), color: color}
+	return nil
+}
+
+// Custom level, if registered
+func customLevelOf(level int) (customLevel, bool) {
+	customLevelsMu.RLock()
+	defer customLevelsMu.RUnlock()
+	c, ok := customLevels[level]
+	return c, ok
+}
+
+// Log at a custom level, see RegisterLevel
+func LogCustom(level int, log string) {
+	Log(level, log)
+}
+
+// Maximum visible width of current tags (prefix excluded, audit tag included), to align auxiliary columns
+func TagWidth() int {
+	w := VisibleLen(auditTag)
+	for _, t := range tags[1:] {
+		if n := VisibleLen(t); n > w {
+			w = n
+		}
+	}
+	customLevelsMu.RLock()
+	defer customLevelsMu.RUnlock()
+	for _, c := range customLevels {
+		if n := VisibleLen(c.tag); n > w {
+			w = n
+		}
+	}
+	return w
+}
+
 //*** Formats ***
 
+// Set a text/template format (e.g "default" or "caller") using TemplateData fields
+// instead of positional verbs, e.g "{{.Tag}} {{.Caller}} {{.Msg}}"
+func SetTemplate(name string, tmpl string) error {
+	if _, err := parseTemplate(tmpl); err != nil {
+		return err
+	}
+	formats[name] = tmpl
+	return nil
+}
+
+// Order of parts of log line, see SetLayout (nil for "default" and "caller" formats)
+var layout []string
+
+// Set order of parts of log line, a permutation of "tag", "msg", "caller" and "time", e.g []string{"time", "msg", "tag", "caller"}.
+// Parts are separated by "layoutsep" format, time being shown with "layouttime" format. An empty order restores formats.
+func SetLayout(order []string) error {
+	if len(order) == 0 {
+		layout = nil
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, p := range order {
+		switch p {
+		case "tag", "msg", "caller", "time":
+		default:
+			return fmt.Errorf("slogan: unknown layout part %q", p)
+		}
+		if seen[p] {
+			return fmt.Errorf("slogan: duplicate layout part %q", p)
+		}
+		seen[p] = true
+	}
+	if len(seen) != 4 {
+		return fmt.Errorf("slogan: layout %q is not a permutation of tag, msg, caller and time", order)
+	}
+	layout = append([]string{}, order...)
+	return nil
+}
+
+// Highlight numbers and quoted strings in messages, on colorized outputs
+func SetSyntaxHighlight(mode bool) {
+	SyntaxHighlight = mode
+}
+
+// Colorize lines of messages starting with '+' (color 14) or '-' (color 15), as in a diff, on colorized outputs
+func SetDiffColors(mode bool) {
+	DiffColors = mode
+}
+
+// Use single character tags, i.e "E" for "error"
+func SetCompactTags(mode bool) {
+	CompactTags = mode
+}
+
+// Prepend an emoji to tags, i.e "❌ error". Disable it on terminals not supporting UTF-8.
+func SetEmoji(mode bool) {
+	Emoji = mode
+}
+
+// Set a new emoji map and return former map
+func SetEmojiMap(n [10]string) [10]string {
+	old := emojis
+	emojis = n
+	return old
+}
+
+// Set a new compact tag map and return former map
+func SetCompactTagMap(n [10]string) [10]string {
+	old := compactTags
+	compactTags = n
+	return old
+}
+
 // Get format map
 func GetFormats() map[string]string {
 	return formats
@@ -272,7 +1233,12 @@ func GetFormats() map[string]string {
 
 // Display format map
 func ShowFormats() {
-	fmt.Printf("%#v\n", formats)
+	FprintFormats(os.Stdout)
+}
+
+// Write format map to w
+func FprintFormats(w io.Writer) {
+	fmt.Fprintf(w, "%#v\n", formats)
 }
 
 // Set a new format map and return former map
@@ -291,7 +1257,12 @@ func GetParts() map[string]bool {
 
 // Display parts map
 func ShowParts() {
-	fmt.Printf("%#v\n", parts)
+	FprintParts(os.Stdout)
+}
+
+// Write parts map to w
+func FprintParts(w io.Writer) {
+	fmt.Fprintf(w, "%#v\n", parts)
 }
 
 // Set new parts map and return former map
@@ -301,6 +1272,20 @@ func SetParts(n map[string]bool) map[string]bool {
 	return old
 }
 
+// Set colorization of a single part ("caller", "tag", "log" or "prefix")
+func SetColorizePart(part string, on bool) error {
+	if _, ok := defaultParts[part]; !ok {
+		return fmt.Errorf("slogan: unknown part %q", part)
+	}
+	parts[part] = on
+	return nil
+}
+
+// Whether a part is colorized
+func IsPartColorized(part string) bool {
+	return parts[part]
+}
+
 // Get status of output, whether it is a terminal or not
 func IsTerminal() bool {
 	return isTerminal
@@ -309,80 +1294,324 @@ func IsTerminal() bool {
 //********** Exported functions for logging ****************************
 
 // silent 0 | emergency 1 | alert 2 | critical 3 | error 4 | warning 5 | notice 6 | info 7 | debug 8 | trace 9
+// Optional key/value pairs are appended as fields, e.g Info("done", "rows", 42) logs "done rows=42"
 
 // Silent a log while keeping it
-func Silent(log string) {
-	Log(Lsilent, log)
+func Silent(log string, kv ...interface{}) {
+	logKV(Lsilent, log, kv)
 }
 
 // Emegency log
-func Emergency(log string) {
-	Log(Lemergency, log)
+func Emergency(log string, kv ...interface{}) {
+	logKV(Lemergency, log, kv)
 }
 
 // Alert log
-func Alert(log string) {
-	Log(Lalert, log)
+func Alert(log string, kv ...interface{}) {
+	logKV(Lalert, log, kv)
 }
 
 // Critical log
-func Critical(log string) {
-	Log(Lcritical, log)
+func Critical(log string, kv ...interface{}) {
+	logKV(Lcritical, log, kv)
 }
 
 // Error log
-func Error(log string) {
-	Log(Lerror, log)
+func Error(log string, kv ...interface{}) {
+	logKV(Lerror, log, kv)
 }
 
 // Warning log
-func Warning(log string) {
-	Log(Lwarning, log)
+func Warning(log string, kv ...interface{}) {
+	logKV(Lwarning, log, kv)
 }
 
 // Notice log
-func Notice(log string) {
-	Log(Lnotice, log)
+func Notice(log string, kv ...interface{}) {
+	logKV(Lnotice, log, kv)
 }
 
 // Info log
-func Info(log string) {
-	Log(Linfo, log)
+func Info(log string, kv ...interface{}) {
+	logKV(Linfo, log, kv)
 }
 
-// Debug log
-func Debug(log string) {
-	Log(Ldebug, log)
+// Audit log, always emitted whatever verbosity, on audit output if any
+func Audit(log string, kv ...interface{}) {
+	logKV(Laudit, log, kv)
 }
 
-// Trace log
-// Use 'empty' format for empty thing to be trace
-func Trace(trace interface{}) {
-	if fmt.Sprintf("%v", trace) == "[]" {
-		Log(Ltrace, fmt.Sprintf(formats["empty"], trace))
-	} else {
-		Log(Ltrace, fmt.Sprintf(formats["trace"], trace))
+// Debug, Trace and TraceCall are in debug.go (no-op versions in nodebug.go)
+
+// Verbs allowed by TraceVerbs
+var traceVerbs = map[string]bool{"%v": true, "%+v": true, "%#v": true, "%T": true, "%s": true, "%q": true, "%d": true, "%x": true, "%p": true}
+
+// Check verbs given to TraceVerbs
+func checkVerbs(verbs []string) error {
+	for _, v := range verbs {
+		if !traceVerbs[v] {
+			return fmt.Errorf("slogan: invalid trace verb %q", v)
+		}
 	}
+	return nil
 }
+
 // Silent trace and avoid 'declared and not used' build errors
 func Trace_(trace interface{}) {}
 
-// Trace log with caller punctually
-func TraceCall(trace interface{}) {
-	TraceCaller = true
-	defer SetTraceCaller(false)
-	Trace(trace)
-}
 // Silent trace and avoid 'declared and not used' build errors
 func TraceCall_(trace interface{}) {}
 
-// Log runtime infos as debug
-func Runtime() {
-	incr_offset()
+// Return v if err is nil, otherwise log err as critical and exit
+func Must[T any](v T, err error) T {
+	if err != nil {
+		Log(Lcritical, err.Error())
+		if ExitOnError == false {
+			Flush()
+			ExitFunc(Lcritical)
+		}
+	}
+	return v
+}
+
+// Check an invariant : if cond is false, log msg as critical with location of caller, then exit if ExitOnError=true
+// or panic otherwise. Nothing is done if cond is true.
+func Assert(cond bool, msg string) {
+	if cond {
+		return
+	}
+	fn_, line := where(1)
+	Log(Lcritical, fmt.Sprintf(formats["assert"], msg, callerWhere(fn_, line)))
+	if ExitOnError == false {
+		panic(msg)
+	}
+}
+
+// Log an error, appending a "kind" field with label of each sentinel error matching err (errors.Is),
+// e.g map[error]string{context.DeadlineExceeded: "timeout"}
+func LogErrorTagged(level int, err error, sentinels map[error]string) {
+	if err == nil {
+		return
+	}
+	labels := []string{}
+	for s, label := range sentinels {
+		if errors.Is(err, s) {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	kv := []interface{}{}
+	for _, label := range labels {
+		kv = append(kv, "kind", label)
+	}
+	logKV(level, err.Error(), kv)
+}
+
+// Lines collected per level, for FlushCollected
+var collected = map[int][]string{}
+var collectedMu sync.Mutex
+
+// Collect a line for a later block log with FlushCollected, e.g per-item results of a batch job
+func Collect(level int, line string) {
+	collectedMu.Lock()
+	defer collectedMu.Unlock()
+	collected[level] = append(collected[level], line)
+}
+
+// Log header then all lines collected for level as a bulleted list, and clear them
+func FlushCollected(level int, header string) {
+	collectedMu.Lock()
+	lines := collected[level]
+	delete(collected, level)
+	collectedMu.Unlock()
+	Log(level, header)
+	for _, line := range lines {
+		Log(level, fmt.Sprintf(formats["bullet"], line))
+	}
+}
+
+// Message counts per summary key, for PrintSummary
+var summaries = map[string]int{}
+var summariesMu sync.Mutex
+
+// Summary key marker, counted instead of being rendered as a field
+type summaryKey string
+
+// Attach a summary key to a message, to be passed among key/value pairs, e.g
+// Error("fetch failed", SummaryKey("timeout"), "url", u). Messages are tallied by key
+// whether emitted or not (verbosity, throttling...), see PrintSummary.
+func SummaryKey(key string) interface{} {
+	return summaryKey(key)
+}
+
+// Log count of messages per summary key, most frequent first, at notice level
+func PrintSummary() {
+	summariesMu.Lock()
+	keys := make([]string, 0, len(summaries))
+	for k := range summaries {
+		keys = append(keys, k)
+	}
+	counts := summaries
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = fmt.Sprintf(formats["summary"], k, counts[k])
+	}
+	summariesMu.Unlock()
+	for _, line := range lines {
+		Log(Lnotice, line)
+	}
+}
+
+// Messages already logged by Once
+var onces = map[string]bool{}
+var oncesMu sync.Mutex
+
+// Log a message only the first time it is seen, repeats being dropped for the process lifetime
+func Once(level int, log string) {
+	oncesMu.Lock()
+	seen := onces[log]
+	onces[log] = true
+	oncesMu.Unlock()
+	if !seen {
+		Log(level, log)
+	}
+}
+
+// Forget messages seen by Once
+func ResetOnce() {
+	oncesMu.Lock()
+	defer oncesMu.Unlock()
+	onces = map[string]bool{}
+}
+
+// Log runtime infos as debug
+func Runtime() {
+	incr_offset()
 	defer decr_offset()
 	Debug(fmt.Sprintf(formats["runtime"], runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.Compiler, runtime.GOROOT()))
 }
 
+// Show memory statistics at debug level, humanized, e.g for quick leak hunts
+func MemStats() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	incr_offset()
+	defer decr_offset()
+	Debug(fmt.Sprintf(formats["memstats"], Bytes(int64(m.Alloc)), Bytes(int64(m.TotalAlloc)), Bytes(int64(m.Sys)), m.NumGC))
+}
+
+// Log a classic hexdump of binary data at given level, one log entry per 16 bytes :
+// offset, bytes in hexadecimal and printable ASCII characters ('.' for others).
+func Hexdump(level int, b []byte) {
+	for off := 0; off < len(b); off += 16 {
+		chunk := b[off:]
+		if len(chunk) > 16 {
+			chunk = chunk[:16]
+		}
+		var hex, ascii strings.Builder
+		for i := 0; i < 16; i++ {
+			if i == 8 {
+				hex.WriteByte(' ')
+			}
+			if i >= len(chunk) {
+				hex.WriteString("   ")
+				continue
+			}
+			fmt.Fprintf(&hex, "%02x ", chunk[i])
+			if chunk[i] >= 0x20 && chunk[i] < 0x7f {
+				ascii.WriteByte(chunk[i])
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		Log(level, fmt.Sprintf(formats["hexdump"], off, hex.String(), ascii.String()))
+	}
+}
+
+// Log key/value pairs at given level, one log entry per pair, keys being sorted and values aligned
+func KeyValues(level int, kv map[string]string) {
+	keys := make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, 2*len(kv))
+	for _, k := range keys {
+		pairs = append(pairs, k, kv[k])
+	}
+	keyValues(level, pairs)
+}
+
+// Log key/value pairs like KeyValues, in given order, e.g KeyValuesOrdered(Linfo, "host", h, "port", p).
+// A lone trailing key gets an empty value.
+func KeyValuesOrdered(level int, kv ...string) {
+	keyValues(level, kv)
+}
+
+// Log flattened key/value pairs with aligned values
+func keyValues(level int, kv []string) {
+	if len(kv)%2 == 1 {
+		kv = append(kv, "")
+	}
+	w := 0
+	for i := 0; i < len(kv); i += 2 {
+		if n := VisibleLen(kv[i]); n > w {
+			w = n
+		}
+	}
+	for i := 0; i < len(kv); i += 2 {
+		// same stack depth as level functions, for caller
+		emit(level, fmt.Sprintf(formats["keyvalue"], kv[i]+strings.Repeat(" ", w-VisibleLen(kv[i])), kv[i+1]), record{})
+	}
+}
+
+// Log an ASCII table at given level, one log entry per line.
+// Column widths are computed from content.
+func Table(level int, headers []string, rows [][]string) {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = VisibleLen(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if l := VisibleLen(cell); l > widths[i] {
+				widths[i] = l
+			}
+		}
+	}
+	sep := "+"
+	for _, w := range widths {
+		sep += strings.Repeat("-", w+2) + "+"
+	}
+	line := func(cells []string) string {
+		Str := "|"
+		for i, w := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			Str += " " + cell + strings.Repeat(" ", w-VisibleLen(cell)) + " |"
+		}
+		return Str
+	}
+	Log(level, sep)
+	Log(level, line(headers))
+	Log(level, sep)
+	for _, row := range rows {
+		Log(level, line(row))
+	}
+	Log(level, sep)
+}
+
 // Increment stack depth offset
 func incr_offset() {
 	offset = offset + 1
@@ -396,58 +1625,897 @@ func decr_offset() {
 // Main log function.
 // 1st argument is level integer, 2nd argument log string
 func Log(level int, log string) {
-	if Verbosity >= level {
+	emit(level, log, record{})
+}
+
+// Log a message computed lazily : fn is only called if level passes verbosity,
+// avoiding to build strings that would be dropped.
+func LogFunc(level int, fn func() string) {
+	logFunc(level, fn)
+}
+
+// Log without trailing newline, e.g for protocols framing their own records or
+// for building a line incrementally. Colors are still applied if output is colorized.
+func LogNoNewline(level int, log string) {
+	// called directly, one frame less than level functions
+	emit(level, log, record{noNewline: true, skip: -1})
+}
+
+// Log with tag and message in given color for this call only, e.g "Green" for a success at info level.
+// An unknown color returns an error, message being logged with color of level.
+func ColorLog(level int, colorName string, log string) error {
+	// called directly, one frame less than level functions
+	if paint(colorName, "x") == "x" {
+		emit(level, log, record{skip: -1})
+		return fmt.Errorf("slogan: unknown color %q", colorName)
+	}
+	emit(level, log, record{skip: -1, color: colorName})
+	return nil
+}
+
+// Whether a message would be written at level, without logging it : all gating is applied
+// (verbosity, level mask, empty messages, drop patterns, quiet hours and throttling).
+func WouldLog(level int, log string) bool {
+	level = effectiveLevel(level)
+	if (level == Lsilent && SilentToHooks == true) || !enabled(level) {
+		return false
+	}
+	if NoEmpty == true && len(log) == 0 {
+		return false
+	}
+	return !drop(log, false) && !quiet(level, false) && !throttling(level)
+}
+
+// Log and return whether log was actually emitted, i.e not gated by verbosity or options
+func TryLog(level int, log string) bool {
+	// called directly, one frame less than level functions
+	return emit(level, log, record{skip: -1})
+}
+
+//****** Internal functions *************************************
+
+// Prefix of messages of a level, see SetLevelPrefix
+func levelPrefix(level int) string {
+	levelPrefixesMu.RLock()
+	defer levelPrefixesMu.RUnlock()
+	return levelPrefixes[level]
+}
+
+// Level actually used for a log, after remapping and capping
+func effectiveLevel(level int) int {
+	remapsMu.RLock()
+	to, ok := remaps[level]
+	remapsMu.RUnlock()
+	if ok {
+		level = to
+	}
+	if max := int(atomic.LoadInt32(&maxLevel)); max > Lsilent && level > Lsilent && level < max {
+		level = max
+	}
+	return level
+}
+
+// Most severe level emitted, 0 if none
+var highest int32
+
+// Record level of an emitted log, if most severe
+func seen(level int) {
+	if level <= Lsilent || level > Ltrace {
+		return
+	}
+	for {
+		h := atomic.LoadInt32(&highest)
+		if h != 0 && int(h) <= level {
+			return
+		}
+		if atomic.CompareAndSwapInt32(&highest, h, int32(level)) {
+			return
+		}
+	}
+}
+
+// Most severe level emitted so far, e.g Lerror, Lsilent if none (audit and custom levels are not considered)
+func HighestLevel() int {
+	return int(atomic.LoadInt32(&highest))
+}
+
+// Process exit code reflecting most severe level emitted : the level for error or worse
+// (or warning if WarningAsError=true), 0 otherwise. Intended usage : os.Exit(slogan.ExitCode())
+func ExitCode() int {
+	h := HighestLevel()
+	if h > Lsilent && (h < Lwarning || (h == Lwarning && WarningAsError == true)) {
+		return h
+	}
+	return 0
+}
+
+// Whether a level passes verbosity and level mask, audit always passing
+func enabled(level int) bool {
+	if level == Laudit {
+		return true
+	}
+	return GetVerbosity() >= level && (LevelMask == 0 || LevelMask&(1<<uint(level)) != 0)
+}
+
+// Log a message computed only if level passes verbosity
+func logFunc(level int, fn func() string) {
+	log := ""
+	if enabled(effectiveLevel(level)) {
+		log = fn()
+	}
+	// still processed for exit on error
+	emit(level, log, record{})
+}
+
+// Log with key/value fields
+func logKV(level int, log string, kv []interface{}) {
+	emit(level, log, record{fields: fieldsOf(kv)})
+}
+
+// Log processing, return whether log was emitted. Fields of r are appended to log.
+func emit(level int, log string, r record) bool {
+	emitted := false
+	level = effectiveLevel(level)
+	msg := log
+	if len(r.fields) > 0 {
+		log += fieldsText(r.fields)
+	}
+	if level == Lsilent && SilentToHooks == true {
+		if !drop(log, true) {
+			r.msg = truncate(sanitize(msg))
+			hook(level, truncate(sanitize(log)), r)
+		}
+	} else if enabled(level) {
 		allow := true
 		if NoEmpty == true && len(log) == 0 {
 			allow = false
 		}
 		if allow {
-			Str := logfmt(level, log)
-			logger.Println(Str)
+			allow = !drop(log, true)
+		}
+		if allow {
+			allow = !quiet(level, true)
+		}
+		if allow {
+			before := log
+			allow, log = throttled(level, log)
+			msg += strings.TrimPrefix(log, before)
+		}
+		if allow {
+			r.msg = truncate(sanitize(msg))
+			write(level, truncate(sanitize(log)), r)
+			emitted = true
+			seen(level)
 		}
 	}
-	if ((level < Lwarning) || (level == Lwarning && WarningAsError == true)) && (ExitOnError == true) {
-		incr_offset()
-		defer decr_offset()
-		incr_offset()
-		defer decr_offset()
-		Debug(fmt.Sprintf(formats["fatal"], level))
-		os.Exit(level)
+	// silent messages are never fatal
+	if level > Lsilent && ((level < Lwarning) || (level == Lwarning && WarningAsError == true)) && (ExitOnError == true) {
+		// exit reason is always shown, at triggering level, on its own line
+		fatal := fmt.Sprintf(formats["fatal"], level)
+		write(level, fatal, record{msg: fatal})
+		// do not lose pending asynchronous entries, among them the cause of exit
+		Flush()
+		ExitFunc(level)
 	}
+	return emitted
 }
 
-//****** Internal functions *************************************
+// Write a log entry to output and additional outputs, each one being rendered according its own colorization
+func write(level int, log string, r record) {
+	// caller is only looked for if shown, runtime.Caller being costly
+	fn_, line := "", 0
+	if TraceCaller == true && (CallerMinLevel <= Lsilent || level <= CallerMinLevel) {
+		fn_, line = where(4 + offset + r.skip)
+	}
+	nl := !r.noNewline
+	// prefix function may log in turn
+	prefix := currentPrefix()
+	d := startDelivery()
+	outputsMu.Lock()
+	entryPrefix = prefix
+	entrySeq = atomic.AddUint64(&seq, 1)
+	if ShowDelta == true {
+		entryDelta = delta(nowFunc())
+	}
+	if events != nil {
+		// events always have a caller, even if not shown
+		efn, eline := fn_, line
+		if efn == "" {
+			efn, eline = where(4 + offset + r.skip)
+		}
+		*events = append(*events, event(level, r.msg, efn, eline, r.fields))
+	}
+	var notify func()
+	switch {
+	case level == Laudit && auditLogger != nil:
+		l, Str := auditLogger, entry(level, log, fn_, line, r, auditTerminal || ForceColorize)
+		notify = d.deliver(func() {
+			put(l, level, Str, nl, JSON)
+		}, func() {
+			publish(Str)
+		})
+	case sink != nil:
+		f, Str := sink, entry(level, log, fn_, line, r, true)
+		notify = d.deliver(nil, func() {
+			f(level, Str)
+			publish(Str)
+		})
+	default:
+		Str := entry(level, log, fn_, line, r, isTerminal || ForceColorize)
+		targets := outputs.targets()
+		Strs := make([]string, len(targets))
+		raws := make([]bool, len(targets))
+		for i, o := range targets {
+			Strs[i], raws[i] = o.render(level, log, fn_, line, r), o.raw()
+		}
+		notify = d.deliver(func() {
+			put(logger, level, Str, nl, JSON)
+			for i, o := range targets {
+				put(o.l, level, Strs[i], nl, raws[i])
+			}
+		}, func() {
+			publish(Str)
+		})
+	}
+	// sink and subscribers may log in turn, queueing is done unlocked
+	outputsMu.Unlock()
+	if notify != nil {
+		notify()
+	}
+}
+
+// Deliver a log entry to subscribers only, without writing it
+func hook(level int, log string, r record) {
+	prefix := currentPrefix()
+	d := startDelivery()
+	outputsMu.Lock()
+	entryPrefix = prefix
+	Str := entry(level, log, "", 0, r, false)
+	notify := d.deliver(nil, func() {
+		publish(Str)
+	})
+	outputsMu.Unlock()
+	if notify != nil {
+		notify()
+	}
+}
+
+// Duration since previous entry, 0 for first one (outputsMu must be locked)
+func delta(now time.Time) time.Duration {
+	d := time.Duration(0)
+	if !lastEntry.IsZero() {
+		d = now.Sub(lastEntry)
+	}
+	lastEntry = now
+	return d
+}
+
+// Prefix of a new entry, dynamic or static
+func currentPrefix() string {
+	if prefixFunc != nil {
+		return prefixFunc()
+	}
+	return tags[0]
+}
+
+// Render a log entry for an additional output
+func (o *out) render(level int, log string, fn_ string, line int, r record) string {
+	switch o.format {
+	case "human":
+		return logfmt(level, log, fn_, line, r, o.color || ForceColorize)
+	case "json":
+		return jsonfmt(level, log, fn_, line, r)
+	case "logfmt":
+		return kvfmt(level, log, fn_, line, r)
+	}
+	return entry(level, log, fn_, line, r, o.color || ForceColorize)
+}
+
+// Whether entries of output are structured, i.e written without legacy logger flags
+func (o *out) raw() bool {
+	return o.format == "json" || o.format == "logfmt" || (o.format == "" && JSON == true)
+}
+
+// Render a log entry, as JSON or text
+func entry(level int, log string, fn_ string, line int, r record, color bool) string {
+	if JSON == true {
+		return jsonfmt(level, log, fn_, line, r)
+	}
+	return logfmt(level, log, fn_, line, r, color)
+}
+
+// Print a rendered log entry with a legacy logger, followed by a newline if nl=true.
+// Structured entries (raw=true) are written as is, without legacy logger flags.
+// Writers needing level of entries (see levelWriter) get it along entry.
+func put(l *log.Logger, level int, Str string, nl bool, raw bool) {
+	if w, ok := l.Writer().(levelWriter); ok {
+		if !raw {
+			var b bytes.Buffer
+			log.New(&b, l.Prefix(), l.Flags()).Print(Str)
+			Str = strings.TrimSuffix(b.String(), "\n")
+		}
+		if nl {
+			Str += "\n"
+		}
+		w.writeLevel(level, Str)
+		return
+	}
+	if raw {
+		if nl {
+			Str += "\n"
+		}
+		io.WriteString(l.Writer(), Str)
+		return
+	}
+	if nl {
+		l.Println(Str)
+		return
+	}
+	// legacy logger always ends with a newline : render flags aside and drop it
+	var b bytes.Buffer
+	log.New(&b, l.Prefix(), l.Flags()).Print(Str)
+	l.Writer().Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
+
+// Subscribers of rendered log entries
+var subscribers = map[int]chan string{}
+var subscribersId = 0
+var subscribersMu sync.Mutex
+
+// Size of subscriber channel buffer
+const subscriberBuffer = 64
+
+// Subscribe to rendered log entries.
+// Return a channel receiving each new entry and a function to unsubscribe, which closes the channel.
+// A slow subscriber never blocks logging : entries are dropped for it when its channel is full.
+func Subscribe() (<-chan string, func()) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	subscribersId++
+	id := subscribersId
+	ch := make(chan string, subscriberBuffer)
+	subscribers[id] = ch
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			subscribersMu.Lock()
+			defer subscribersMu.Unlock()
+			delete(subscribers, id)
+			close(ch)
+		})
+	}
+}
+
+// Send a rendered log entry to all subscribers, without blocking.
+// Subscribers always receive plain text, even if outputs are colorized.
+func publish(Str string) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	if len(subscribers) == 0 {
+		return
+	}
+	Str = StripANSI(Str)
+	for _, ch := range subscribers {
+		select {
+		case ch <- Str:
+		default:
+		}
+	}
+}
 
 // Log formatter
-func logfmt(level int, log string) string {
+func logfmt(level int, log string, fn_ string, line int, r record, color bool) string {
+	// warnings considered as errors are rendered as errors
+	if level == Lwarning && WarningAsError == true {
+		level = Lerror
+	}
 	Fmt := formats["default"]
-	Tag := tags[level]
+	Tag := tagOf(level)
+	if CompactTags == true {
+		Tag = compactTag(level)
+	}
+	if Emoji == true && level >= 0 && level < len(emojis) && emojis[level] != "" {
+		Tag = emojis[level] + " " + Tag
+	}
 
 	Str := ""
 	Caller := ""
-	var fn_ string = ""
-	var line int
 
-	_, fn_, line, _ = runtime.Caller(3 + offset)
+	// legacy logger adds its own newline
+	log = strings.TrimSuffix(log, "\n")
+	log = levelPrefix(level) + log
+	if SyntaxHighlight == true && color == true && Colorize == true {
+		log = highlight(log)
+	}
+	if DiffColors == true && color == true && Colorize == true {
+		log = diffColor(log)
+	}
+	var rest []string
+	if AlignMultiline == true && strings.Contains(log, "\n") {
+		lines := strings.Split(log, "\n")
+		log, rest = lines[0], lines[1:]
+		for i := range rest {
+			rest[i] = paintPart(color, "log", level, r.color, rest[i])
+		}
+	}
+	Log := paintPart(color, "log", level, r.color, log)
 
-	if TraceCaller == true {
-		fn := ""
-		if CallerBase == true {
-			fn = path.Base(fn_)
-		} else {
-			fn = fn_
+	if layout != nil {
+		if fn_ != "" {
+			Caller = colorize(color, "caller", 10, callerWhere(fn_, line))
 		}
-		Caller := colorize("caller", 10, fmt.Sprintf(formats["where"], fn, line))
-		Str = fmt.Sprintf(formats["caller"], colorize("tag", level, Tag), colorize("log", level, log), Caller)
+		Str = layoutfmt(paintPart(color, "tag", level, r.color, Tag), Log, Caller)
+	} else if fn_ != "" {
+		Caller := colorize(color, "caller", 10, callerWhere(fn_, line))
+		Str = render(formats["caller"], paintPart(color, "tag", level, r.color, Tag), Log, Caller)
 	} else {
-		Str = fmt.Sprintf(Fmt, colorize("tag", level, Tag), colorize("log", level, log), Caller)
+		Str = render(Fmt, paintPart(color, "tag", level, r.color, Tag), Log, Caller)
+	}
+	Str = colorize(color, "prefix", 0, entryPrefix) + leading() + Str
+	if rest != nil {
+		Str = alignLines(Str, Log, rest)
 	}
 	return Str
 }
 
-// Log colorization
-func colorize(what string, level int, str string) string {
-	if isTerminal == false && ForceColorize == false {
+// Assemble parts of log line in layout order, not shown caller being omitted
+func layoutfmt(Tag string, Log string, Caller string) string {
+	cols := make([]string, 0, len(layout))
+	for _, p := range layout {
+		switch p {
+		case "tag":
+			cols = append(cols, Tag)
+		case "msg":
+			cols = append(cols, Log)
+		case "caller":
+			if Caller != "" {
+				cols = append(cols, Caller)
+			}
+		case "time":
+			cols = append(cols, nowFunc().Format(formats["layouttime"]))
+		}
+	}
+	return strings.Join(cols, formats["layoutsep"])
+}
+
+// Insert continuation lines of a message after its first line, indented to its column
+func alignLines(Str string, Log string, rest []string) string {
+	i := strings.LastIndex(Str, Log)
+	if i < 0 {
+		return Str + "\n" + strings.Join(rest, "\n")
+	}
+	// blank out what precedes message, keeping tabs
+	var pad strings.Builder
+	pad.WriteString(strings.Repeat(" ", flagsWidth()))
+	for _, r := range StripANSI(Str[:i]) {
+		switch {
+		case r == '\t':
+			pad.WriteRune(r)
+		case isWide(r):
+			pad.WriteString("  ")
+		default:
+			pad.WriteByte(' ')
+		}
+	}
+	var b strings.Builder
+	b.WriteString(Str[:i+len(Log)])
+	for _, r := range rest {
+		b.WriteString("\n" + pad.String() + r)
+	}
+	b.WriteString(Str[i+len(Log):])
+	return b.String()
+}
+
+// Width of date and time written by legacy logger
+func flagsWidth() int {
+	n := 0
+	flags := logger.Flags()
+	if flags&Ldate != 0 {
+		n += len("2009/01/23 ")
+	}
+	if flags&(Ltime|Lmicroseconds) != 0 {
+		n += len("01:23:23 ")
+		if flags&Lmicroseconds != 0 {
+			n += len(".123123")
+		}
+	}
+	return n
+}
+
+// Get file and line of caller, skip being the depth from caller of this function.
+// If CallerSkipRuntime is set, frames of Go runtime and standard library are skipped,
+// as well as frames of files containing a substring given to AddCallerIgnore.
+// If CallerPackage is set, package qualified function name is returned instead of file.
+func where(skip int) (string, int) {
+	callerIgnoresMu.RLock()
+	ignores := callerIgnores
+	callerIgnoresMu.RUnlock()
+	root := ""
+	if CallerSkipRuntime == true {
+		root = runtime.GOROOT()
+	}
+	if root != "" || len(ignores) > 0 {
+		pc := make([]uintptr, 32)
+		n := runtime.Callers(skip+2, pc)
+		frames := runtime.CallersFrames(pc[:n])
+		for {
+			f, more := frames.Next()
+			if !(root != "" && strings.HasPrefix(f.File, root+"/")) && !ignored(f.File, ignores) {
+				if CallerPackage == true {
+					return f.Function, f.Line
+				}
+				return f.File, f.Line
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	pc, file, line, _ := runtime.Caller(skip + 1)
+	if CallerPackage == true {
+		if f := runtime.FuncForPC(pc); f != nil {
+			return f.Name(), line
+		}
+	}
+	return file, line
+}
+
+// Stack frame, see Stack
+type Frame struct {
+	File string
+	Line int
+	Func string // package qualified function name
+}
+
+// Stack of calling goroutine as structured frames, e.g to attach to custom error reports.
+// skip 0 starts at caller of Stack, depth is maximum count of frames (32 if depth <= 0).
+func Stack(skip int, depth int) []Frame {
+	if depth <= 0 {
+		depth = 32
+	}
+	pc := make([]uintptr, depth)
+	n := runtime.Callers(skip+2, pc)
+	if n == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(pc[:n])
+	stack := make([]Frame, 0, n)
+	for {
+		f, more := frames.Next()
+		stack = append(stack, Frame{File: f.File, Line: f.Line, Func: f.Function})
+		if !more || len(stack) == depth {
+			break
+		}
+	}
+	return stack
+}
+
+// Whether file contains one of ignored substrings
+func ignored(file string, ignores []string) bool {
+	for _, i := range ignores {
+		if strings.Contains(file, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// Numbers and quoted strings in messages
+var highlights = regexp.MustCompile(`"[^"]*"|\b\d+(\.\d+)?\b`)
+
+// Colorize numbers and quoted strings of a message
+func highlight(log string) string {
+	return highlights.ReplaceAllStringFunc(log, func(m string) string {
+		if m[0] == '"' {
+			return setcolor("string", 13, m)
+		}
+		return setcolor("number", 12, m)
+	})
+}
+
+// Colorize lines of a message starting with '+' or '-', as in a diff
+func diffColor(log string) string {
+	lines := strings.Split(log, "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+"):
+			lines[i] = setcolor("diff", 14, l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = setcolor("diff", 15, l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Caller location, as configured
+func callerWhere(fn_ string, line int) string {
+	return fmt.Sprintf(formats["where"], callerPath(fn_), line)
+}
+
+// Path of caller file, as configured
+func callerPath(fn_ string) string {
+	if CallerPackage == true {
+		return fn_
+	}
+	if CallerDepth > 0 {
+		return lastSegments(fn_, CallerDepth)
+	}
+	if CallerBase == true {
+		return path.Base(fn_)
+	}
+	return fn_
+}
+
+// Keep n last segments of a slash separated path
+func lastSegments(p string, n int) string {
+	i := len(p)
+	for ; n > 0; n-- {
+		i = strings.LastIndexByte(p[:i], '/')
+		if i < 0 {
+			return p
+		}
+	}
+	return p[i+1:]
+}
+
+// Tag of a level
+func tagOf(level int) string {
+	if level == Laudit {
+		return auditTag
+	}
+	if c, ok := customLevelOf(level); ok {
+		return c.tag
+	}
+	if level < 0 || level >= len(tags) {
+		// unregistered level, e.g above trace
+		return fmt.Sprintf("%-9s", fmt.Sprintf("level%d", level))
+	}
+	return tags[level]
+}
+
+// Single character tag of a level, from compact tag map or first letter of tag
+func compactTag(level int) string {
+	if level >= 0 && level < len(compactTags) && compactTags[level] != "" {
+		return compactTags[level]
+	}
+	t := strings.TrimSpace(tagOf(level))
+	if t == "" {
+		return ""
+	}
+	r, _ := utf8.DecodeRuneInString(t)
+	return strings.ToUpper(string(r))
+}
+
+// Render a log line format, either Sprintf style or text/template style ("{{" in format)
+func render(Fmt string, Tag string, Log string, Caller string) string {
+	if !strings.Contains(Fmt, "{{") {
+		return fmt.Sprintf(Fmt, Tag, Log, Caller)
+	}
+	t, err := parseTemplate(Fmt)
+	if err != nil {
+		return fmt.Sprintf("%s %s %s", Tag, Caller, Log)
+	}
+	var b strings.Builder
+	t.Execute(&b, TemplateData{Tag: Tag, Msg: Log, Caller: Caller})
+	return b.String()
+}
+
+// Parse a template format, once
+func parseTemplate(Fmt string) (*template.Template, error) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	if t, ok := templates[Fmt]; ok {
+		return t, nil
+	}
+	t, err := template.New("slogan").Parse(Fmt)
+	if err != nil {
+		return nil, err
+	}
+	templates[Fmt] = t
+	return t, nil
+}
+
+// Fields from key/value pairs.
+// A lone trailing element is the value of a "!BADKEY" key.
+// Summary keys are counted, not rendered.
+func fieldsOf(kv []interface{}) []field {
+	var fields []field
+	for i := 0; i < len(kv); i++ {
+		if k, ok := kv[i].(summaryKey); ok {
+			summariesMu.Lock()
+			summaries[string(k)]++
+			summariesMu.Unlock()
+			continue
+		}
+		if i+1 == len(kv) {
+			fields = append(fields, field{"!BADKEY", kv[i]})
+			break
+		}
+		fields = append(fields, field{fmt.Sprint(kv[i]), kv[i+1]})
+		i++
+	}
+	return fields
+}
+
+// Render key/value fields to be appended to a log message
+func fieldsText(fields []field) string {
+	s := ""
+	for _, f := range fields {
+		s += fmt.Sprintf(formats["field"], f.key, f.value)
+	}
+	return s
+}
+
+// Leading fields of log entry
+func leading() string {
+	Lead := ""
+	if ShowSeq == true {
+		Lead += fmt.Sprintf(formats["seq"], entrySeq)
+	}
+	if ShowElapsed == true {
+		Lead += fmt.Sprintf(formats["since"], sinceFunc(start).Seconds())
+	}
+	if ShowDelta == true {
+		Lead += fmt.Sprintf(formats["delta"], entryDelta.Seconds())
+	}
+	if ShowHost == true {
+		Lead += fmt.Sprintf(formats["host"], getHostname())
+	}
+	if ShowPID == true {
+		Lead += fmt.Sprintf(formats["pid"], os.Getpid())
+	}
+	if ShowGoroutine == true {
+		Lead += fmt.Sprintf(formats["goroutine"], goroutineID())
+	}
+	return Lead
+}
+
+// Cached hostname
+var hostname string
+var hostnameOnce sync.Once
+
+// Get hostname, resolved once
+func getHostname() string {
+	hostnameOnce.Do(func() {
+		h, err := os.Hostname()
+		if err != nil {
+			h = "localhost"
+		}
+		hostname = h
+	})
+	return hostname
+}
+
+// Get current goroutine ID.
+// Go does not expose it, so it is parsed from the "goroutine N [running]:" header
+// of runtime.Stack. This costs a stack capture on each call : use for debugging only.
+func goroutineID() uint64 {
+	b := make([]byte, 64)
+	b = b[:runtime.Stack(b, false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	n, _ := strconv.ParseUint(string(b), 10, 64)
+	return n
+}
+
+// Parse a "HH:MM" time in minutes since midnight
+func parseHHMM(hhmm string) (int, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0, fmt.Errorf("slogan: invalid time %q, HH:MM expected", hhmm)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Check whether a message matches a drop pattern, counting it if count=true
+func drop(log string, count bool) bool {
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+	for _, re := range drops {
+		if re.MatchString(log) {
+			if count {
+				dropped++
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether level is in its quiet hours, counting suppression if count=true
+func quiet(level int, count bool) bool {
+	quietsMu.Lock()
+	defer quietsMu.Unlock()
+	q, ok := quiets[level]
+	if !ok {
+		return false
+	}
+	now := nowFunc().Local()
+	m := now.Hour()*60 + now.Minute()
+	in := false
+	if q.from <= q.to {
+		in = m >= q.from && m < q.to
+	} else {
+		// crossing midnight
+		in = m >= q.from || m < q.to
+	}
+	if in && count {
+		q.suppressed++
+	}
+	return in
+}
+
+// Check throttle of level.
+// Return whether log is allowed, and log possibly completed with count of suppressed logs since last one.
+func throttled(level int, log string) (bool, string) {
+	throttlesMu.Lock()
+	defer throttlesMu.Unlock()
+	t, ok := throttles[level]
+	if !ok {
+		return true, log
+	}
+	now := nowFunc()
+	if !t.last.IsZero() && now.Sub(t.last) < t.interval {
+		t.suppressed++
+		return false, log
+	}
+	if t.suppressed > 0 {
+		log += fmt.Sprintf(formats["throttled"], t.suppressed, now.Sub(t.last).Round(time.Millisecond))
+	}
+	t.last = now
+	t.suppressed = 0
+	return true, log
+}
+
+// Whether level is currently throttled, without updating throttle
+func throttling(level int) bool {
+	throttlesMu.Lock()
+	defer throttlesMu.Unlock()
+	t, ok := throttles[level]
+	return ok && !t.last.IsZero() && nowFunc().Sub(t.last) < t.interval
+}
+
+// Escape control characters of log message if Sanitize=true, a single trailing newline excepted
+func sanitize(log string) string {
+	if Sanitize == false || strings.IndexFunc(log, unicode.IsControl) < 0 {
+		return log
+	}
+	nl := strings.HasSuffix(log, "\n")
+	if nl {
+		log = log[:len(log)-1]
+	}
+	var b strings.Builder
+	for _, r := range log {
+		if !unicode.IsControl(r) {
+			b.WriteRune(r)
+			continue
+		}
+		q := strconv.QuoteRune(r)
+		b.WriteString(q[1 : len(q)-1])
+	}
+	if nl {
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Truncate log message to MaxMessageBytes, on a rune boundary
+func truncate(log string) string {
+	if MaxMessageBytes <= 0 || len(log) <= MaxMessageBytes {
+		return log
+	}
+	i := MaxMessageBytes
+	for i > 0 && !utf8.RuneStart(log[i]) {
+		i--
+	}
+	return log[:i] + formats["truncated"]
+}
+
+// Log colorization, if output allows color
+func colorize(color bool, what string, level int, str string) string {
+	if color == false {
 		return str
 	}
 	if Colorize == true && parts[what] == true {
@@ -457,10 +2525,35 @@ func colorize(what string, level int, str string) string {
 	}
 }
 
+// Colorize a part like colorize, with given color instead of color map if any
+func paintPart(color bool, what string, level int, override string, str string) string {
+	if override != "" && color == true && Colorize == true && parts[what] == true {
+		return paint(override, str)
+	}
+	return colorize(color, what, level, str)
+}
+
 // Set color from color map
 func setcolor(what string, level int, str string) string {
+	Color := colors[level]
+	// tag and message of custom levels have their own color, indexes of colors map above trace being used for other parts
+	if what == "tag" || what == "log" {
+		if c, ok := customLevelOf(level); ok {
+			Color = c.color
+		} else if level > Ltrace && level != Laudit {
+			Color = ""
+		}
+	}
+	if EffectFallback == true && (Color == "Hide" || Color == "Blink") && !supportsEffects() {
+		Color = "Bold"
+	}
+	return paint(Color, str)
+}
+
+// Apply a color or effect by name to a string, unchanged if name is unknown
+func paint(Color string, str string) string {
 	Ret := ""
-	switch colors[level] {
+	switch Color {
 	case "Black":
 		Ret = color.Black(str)
 	case "Red":
@@ -582,24 +2675,74 @@ func setcolor(what string, level int, str string) string {
  *   Terminal
  */
 
-// Terminal size structure
-type winsize struct {
-	Row    uint16
-	Col    uint16
-	Xpixel uint16
-	Ypixel uint16
+// Terminals known to not support Hide and Blink effects properly
+var poorTerms = map[string]bool{
+	"":       true,
+	"dumb":   true,
+	"linux":  true,
+	"cons25": true,
+	"emacs":  true,
+	"vt100":  true,
 }
 
-// Get terminal width
-func getWidth() uint {
-	ws := &winsize{}
-	retCode, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
-		uintptr(syscall.Stdin),
-		uintptr(syscall.TIOCGWINSZ),
-		uintptr(unsafe.Pointer(ws)))
+// Whether a writer is a terminal
+func isTerminalWriter(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
+
+// Whether terminal, from TERM environment variable, supports Hide and Blink effects
+func supportsEffects() bool {
+	return !poorTerms[os.Getenv("TERM")]
+}
 
-	if int(retCode) == -1 {
-		panic(errno)
+// Visible width of a string on a terminal.
+// ANSI CSI sequences (colors, etc.) are ignored and wide runes (CJK, fullwidth) count for two columns.
+func VisibleLen(s string) int {
+	n := 0
+	for _, r := range StripANSI(s) {
+		if isWide(r) {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// Remove ANSI CSI sequences (ESC [ parameters intermediates final), i.e colors, from a string
+func StripANSI(s string) string {
+	if strings.IndexByte(s, 0x1b) < 0 {
+		return s
 	}
-	return uint(ws.Col)
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '[' {
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Whether a rune is displayed on two columns (East Asian wide and fullwidth)
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115f) || // Hangul Jamo
+		(r >= 0x2e80 && r <= 0x303e) || // CJK radicals, punctuation
+		(r >= 0x3041 && r <= 0x33ff) || // Hiragana, Katakana, CJK compatibility
+		(r >= 0x3400 && r <= 0x4dbf) || // CJK extension A
+		(r >= 0x4e00 && r <= 0x9fff) || // CJK unified ideographs
+		(r >= 0xa000 && r <= 0xa4cf) || // Yi
+		(r >= 0xac00 && r <= 0xd7a3) || // Hangul syllables
+		(r >= 0xf900 && r <= 0xfaff) || // CJK compatibility ideographs
+		(r >= 0xfe30 && r <= 0xfe4f) || // CJK compatibility forms
+		(r >= 0xff00 && r <= 0xff60) || // Fullwidth forms
+		(r >= 0xffe0 && r <= 0xffe6) || // Fullwidth signs
+		(r >= 0x1f300 && r <= 0x1f64f) || // Emoji
+		(r >= 0x1f900 && r <= 0x1f9ff) || // Supplemental symbols
+		(r >= 0x20000 && r <= 0x3fffd) // CJK extensions B and beyond
 }
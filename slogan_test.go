@@ -0,0 +1,1404 @@
+package slogan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bclicn/color"
+)
+
+// Reset configuration, log to a buffer without flags, restore defaults at end of test.
+// State being global, tests must not run in parallel.
+func setup(t testing.TB) *bytes.Buffer {
+	t.Helper()
+	Reset()
+	var b bytes.Buffer
+	SetOutput(&b)
+	SetFlags(0)
+	t.Cleanup(Reset)
+	return &b
+}
+
+// Non empty lines of a buffer
+func lines(b *bytes.Buffer) []string {
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+}
+
+func TestShowGoroutine(t *testing.T) {
+	b := setup(t)
+	SetShowGoroutine(true)
+	var wg sync.WaitGroup
+	for _, msg := range []string{"first", "second"} {
+		wg.Add(1)
+		go func(msg string) {
+			defer wg.Done()
+			Error(msg)
+		}(msg)
+		wg.Wait()
+	}
+	re := regexp.MustCompile(`\[(\d+)\] `)
+	ids := map[string]bool{}
+	for _, l := range lines(b) {
+		m := re.FindStringSubmatch(l)
+		if m == nil {
+			t.Fatalf("no goroutine ID in %q", l)
+		}
+		ids[m[1]] = true
+	}
+	if len(ids) != 2 {
+		t.Errorf("want 2 different goroutine IDs, got %v in %q", ids, b.String())
+	}
+}
+
+func TestFprintColors(t *testing.T) {
+	setup(t)
+	var b bytes.Buffer
+	FprintColors(&b)
+	if !strings.HasPrefix(b.String(), "map[int]string{") || !strings.Contains(b.String(), `4:"`+colors[Lerror]+`"`) {
+		t.Errorf("unexpected color map dump %q", b.String())
+	}
+	b.Reset()
+	FprintTags(&b)
+	if !strings.Contains(b.String(), `"error    "`) {
+		t.Errorf("unexpected tag map dump %q", b.String())
+	}
+}
+
+func TestLevelRemap(t *testing.T) {
+	b := setup(t)
+	SetLevelRemap(Linfo, Ldebug)
+	SetVerbosity(Linfo)
+	Info("hidden")
+	if b.Len() != 0 {
+		t.Fatalf("remapped info should be gated by debug verbosity, got %q", b.String())
+	}
+	SetVerbosity(Ldebug)
+	Info("shown")
+	if got := b.String(); !strings.Contains(got, "debug") || !strings.Contains(got, "shown") {
+		t.Errorf("remapped info should render with debug tag, got %q", got)
+	}
+}
+
+func TestMaxMessageBytes(t *testing.T) {
+	b := setup(t)
+	SetMaxMessageBytes(100)
+	// 3 bytes runes : byte 100 is inside a rune
+	Error(strings.Repeat("€", 5000/3) + "xx")
+	got := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(b.String()), "error"))
+	if !strings.HasSuffix(got, "…(truncated)") {
+		t.Fatalf("message not truncated : %q", got)
+	}
+	msg := strings.TrimSuffix(got, "…(truncated)")
+	if len(msg) > 100 || !utf8.ValidString(msg) || msg != strings.Repeat("€", 33) {
+		t.Errorf("want 33 whole runes in 100 bytes, got %d bytes %q", len(msg), msg)
+	}
+}
+
+func TestShowPID(t *testing.T) {
+	b := setup(t)
+	SetShowPID(true)
+	SetShowHost(true)
+	Error("boom")
+	host, _ := os.Hostname()
+	if got := b.String(); !strings.Contains(got, fmt.Sprintf("[%d] ", os.Getpid())) || !strings.Contains(got, host+" ") {
+		t.Errorf("want host %q and PID %d in %q", host, os.Getpid(), got)
+	}
+	b.Reset()
+	SetJSON(true)
+	Error("boom")
+	if got := b.String(); !strings.Contains(got, fmt.Sprintf(`"pid":%d`, os.Getpid())) || !strings.Contains(got, `"host":"`+host+`"`) {
+		t.Errorf("want host and pid keys in %q", got)
+	}
+}
+
+// Writer recording whether it was closed
+type closer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	setup(t)
+	var main, extra closer
+	SetOutput(&main)
+	AddOutput(&extra)
+	Error("boom")
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !main.closed || !extra.closed {
+		t.Errorf("outputs not closed : main %v, extra %v", main.closed, extra.closed)
+	}
+	if output != os.Stderr {
+		t.Errorf("output should fall back to stderr")
+	}
+}
+
+func TestVisibleLen(t *testing.T) {
+	cases := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"héllo", 5},
+		{color.Red("hello"), 5},
+		{"\x1b[1;31mbold\x1b[0m red", 8},
+		{"日本語", 6},
+		{color.Green("日本") + "x", 5},
+	}
+	for _, c := range cases {
+		if got := VisibleLen(c.s); got != c.want {
+			t.Errorf("VisibleLen(%q) = %d, want %d", c.s, got, c.want)
+		}
+	}
+}
+
+func TestPrefixOnce(t *testing.T) {
+	for _, caller := range []bool{false, true} {
+		b := setup(t)
+		SetFlags(Lshortfile)
+		SetTraceCaller(caller)
+		SetPrefix("app: ")
+		Error("boom")
+		if n := strings.Count(b.String(), "app:"); n != 1 {
+			t.Errorf("TraceCaller=%v : want prefix once, got %d in %q", caller, n, b.String())
+		}
+	}
+}
+
+func TestTempVerbosity(t *testing.T) {
+	b := setup(t)
+	restore := TempVerbosity(Ltrace)
+	Log(Ltrace, "inside")
+	restore()
+	Log(Ltrace, "outside")
+	if got := b.String(); !strings.Contains(got, "inside") || strings.Contains(got, "outside") {
+		t.Errorf("trace should only be logged inside scope, got %q", got)
+	}
+	if GetVerbosity() != Lwarning {
+		t.Errorf("verbosity not restored : %d", GetVerbosity())
+	}
+}
+
+func TestTable(t *testing.T) {
+	b := setup(t)
+	Table(Lerror, []string{"host", "status", "load"}, [][]string{{"alpha", "up", "0.5"}, {"b", "down", "12.25"}})
+	ls := lines(b)
+	want := []string{
+		"+-------+--------+-------+",
+		"| host  | status | load  |",
+		"+-------+--------+-------+",
+		"| alpha | up     | 0.5   |",
+		"| b     | down   | 12.25 |",
+		"+-------+--------+-------+",
+	}
+	if len(ls) != len(want) {
+		t.Fatalf("want %d lines, got %q", len(want), b.String())
+	}
+	for i, l := range ls {
+		if !strings.HasSuffix(l, want[i]) {
+			t.Errorf("line %d : want %q, got %q", i, want[i], l)
+		}
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	b := setup(t)
+	now := time.Date(2023, 6, 3, 10, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	SetThrottle(Lerror, time.Second)
+	for i := 0; i < 5; i++ {
+		Error(fmt.Sprintf("msg%d", i))
+		now = now.Add(100 * time.Millisecond)
+	}
+	if ls := lines(b); len(ls) != 1 || !strings.Contains(ls[0], "msg0") {
+		t.Fatalf("want only first message within interval, got %q", b.String())
+	}
+	now = now.Add(time.Second)
+	Error("later")
+	if got := lines(b)[1]; !strings.HasSuffix(got, "later (4 suppressed in last 1.5s)") {
+		t.Errorf("want suppressed count after interval, got %q", got)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	setup(t)
+	ch, unsubscribe := Subscribe()
+	for _, msg := range []string{"one", "two", "three"} {
+		Error(msg)
+	}
+	for _, want := range []string{"one", "two", "three"} {
+		select {
+		case got := <-ch:
+			if !strings.HasSuffix(got, want) {
+				t.Errorf("want %q, got %q", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no entry %q received", want)
+		}
+	}
+	unsubscribe()
+	if _, ok := <-ch; ok {
+		t.Errorf("channel should be closed after unsubscribe")
+	}
+}
+
+func TestSubscribeSlow(t *testing.T) {
+	b := setup(t)
+	_, unsubscribe := Subscribe()
+	defer unsubscribe()
+	for i := 0; i < 2*subscriberBuffer; i++ {
+		Error("flood")
+	}
+	if n := len(lines(b)); n != 2*subscriberBuffer {
+		t.Errorf("slow subscriber should not block logging, got %d entries", n)
+	}
+}
+
+func TestThemeMonochrome(t *testing.T) {
+	setup(t)
+	if err := SetTheme("monochrome"); err != nil {
+		t.Fatal(err)
+	}
+	effects := map[string]bool{"": true, "Bold": true, "Dim": true, "Underline": true, "Invert": true}
+	for level := Lemergency; level <= Ltrace; level++ {
+		if !effects[colors[level]] {
+			t.Errorf("level %d has color %q in monochrome theme", level, colors[level])
+		}
+	}
+	before := fmt.Sprint(colors)
+	if err := SetTheme("nope"); err == nil {
+		t.Errorf("unknown theme should be an error")
+	}
+	if fmt.Sprint(colors) != before {
+		t.Errorf("unknown theme should leave color map unchanged")
+	}
+}
+
+func TestHumanized(t *testing.T) {
+	durations := map[time.Duration]string{
+		500 * time.Nanosecond:   "500ns",
+		1500 * time.Nanosecond:  "1.5µs",
+		250 * time.Millisecond:  "250.0ms",
+		999 * time.Millisecond:  "999.0ms",
+		1500 * time.Millisecond: "1.5s",
+		90 * time.Second:        "1m30s",
+		-2 * time.Second:        "-2.0s",
+	}
+	for d, want := range durations {
+		if got := Duration(d); got != want {
+			t.Errorf("Duration(%d) = %q, want %q", d, got, want)
+		}
+	}
+	sizes := map[int64]string{
+		0:             "0 B",
+		1023:          "1023 B",
+		1024:          "1.0 KiB",
+		1536:          "1.5 KiB",
+		1048576:       "1.0 MiB",
+		1 << 30:       "1.0 GiB",
+		-2048:         "-2.0 KiB",
+		1<<62 + 1<<61: "6.0 EiB",
+	}
+	for n, want := range sizes {
+		if got := Bytes(n); got != want {
+			t.Errorf("Bytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestLevelPrefix(t *testing.T) {
+	b := setup(t)
+	SetLevelPrefix(Lerror, "[ERROR] ")
+	Error("boom")
+	Critical("down")
+	ls := lines(b)
+	if len(ls) != 2 || !strings.HasSuffix(ls[0], "[ERROR] boom") || strings.Contains(ls[1], "[ERROR]") {
+		t.Errorf("prefix should only be on error lines, got %q", b.String())
+	}
+}
+
+func TestWarningAsErrorTag(t *testing.T) {
+	b := setup(t)
+	SetForceColor(true)
+	SetWarningAsError(true)
+	Warning("escalated")
+	got := b.String()
+	if !strings.Contains(got, setcolor("tag", Lerror, tags[Lerror])) || strings.Contains(got, "warning") {
+		t.Errorf("escalated warning should render with error tag and color, got %q", got)
+	}
+	b.Reset()
+	SetWarningAsError(false)
+	Warning("plain")
+	if got := b.String(); !strings.Contains(got, setcolor("tag", Lwarning, tags[Lwarning])) {
+		t.Errorf("warning should keep its tag and color, got %q", got)
+	}
+}
+
+// Replace ExitFunc by a recorder of exit code, -1 if not called
+func fakeExit(t *testing.T) *int {
+	code := -1
+	ExitFunc = func(c int) { code = c }
+	t.Cleanup(func() { ExitFunc = os.Exit })
+	return &code
+}
+
+func TestMust(t *testing.T) {
+	b := setup(t)
+	code := fakeExit(t)
+	if v := Must(42, nil); v != 42 || *code != -1 || b.Len() != 0 {
+		t.Fatalf("success should pass value through silently, got %d, exit %d, %q", v, *code, b.String())
+	}
+	Must(0, errors.New("no config"))
+	if *code != Lcritical || !strings.Contains(b.String(), "critical") || !strings.Contains(b.String(), "no config") {
+		t.Errorf("error should be logged as critical then exit, got exit %d, %q", *code, b.String())
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	b := setup(t)
+	if err := SetTemplate("default", "{{.Msg}} <{{.Tag}}>"); err != nil {
+		t.Fatal(err)
+	}
+	Error("boom")
+	if got := strings.TrimSpace(b.String()); got != "boom <error    >" {
+		t.Errorf("template not applied, got %q", got)
+	}
+	if err := SetTemplate("default", "{{.Msg"); err == nil {
+		t.Errorf("invalid template should be an error")
+	}
+}
+
+func TestCallerSkipRuntime(t *testing.T) {
+	b := setup(t)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lerror)
+	SetCallerSkipRuntime(true)
+	// reflect calls Error : first frame outside slogan is in standard library
+	reflect.ValueOf(Error).Call([]reflect.Value{reflect.ValueOf("boom")})
+	_, _, line, _ := runtime.Caller(0)
+	if want := fmt.Sprintf("slogan_test.go:%d", line-1); !strings.Contains(b.String(), want) {
+		t.Errorf("want user frame %s, got %q", want, b.String())
+	}
+}
+
+func TestCompactTags(t *testing.T) {
+	b := setup(t)
+	SetCompactTags(true)
+	Error("boom")
+	Warning("careful")
+	if ls := lines(b); len(ls) != 2 || !strings.HasPrefix(strings.TrimSpace(ls[0]), "E ") || !strings.HasPrefix(strings.TrimSpace(ls[1]), "W ") {
+		t.Errorf("want compact E and W tags, got %q", b.String())
+	}
+}
+
+func TestOnce(t *testing.T) {
+	b := setup(t)
+	ResetOnce()
+	for i := 0; i < 3; i++ {
+		Once(Lwarning, "option -x is deprecated")
+	}
+	if n := len(lines(b)); n != 1 {
+		t.Fatalf("want one line for repeated message, got %q", b.String())
+	}
+	Once(Lwarning, "option -y is deprecated")
+	if ls := lines(b); len(ls) != 2 || !strings.HasSuffix(ls[1], "option -y is deprecated") {
+		t.Errorf("different message should be logged, got %q", b.String())
+	}
+}
+
+func TestTryLog(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lwarning)
+	if TryLog(Linfo, "hidden") {
+		t.Errorf("TryLog below verbosity should return false")
+	}
+	if !TryLog(Lerror, "shown") || !strings.Contains(b.String(), "shown") {
+		t.Errorf("TryLog above verbosity should return true and log, got %q", b.String())
+	}
+}
+
+func TestShowElapsed(t *testing.T) {
+	b := setup(t)
+	begin := time.Date(2023, 6, 3, 10, 0, 0, 0, time.UTC)
+	SetStart(begin)
+	SetSinceFunc(func(t time.Time) time.Duration { return begin.Add(1234 * time.Millisecond).Sub(t) })
+	SetShowElapsed(true)
+	Error("boom")
+	if got := strings.TrimSpace(b.String()); !strings.HasPrefix(got, "[+1.234s]") {
+		t.Errorf("want elapsed prefix [+1.234s], got %q", got)
+	}
+}
+
+func TestFields(t *testing.T) {
+	cases := []struct {
+		kv   []interface{}
+		want string
+	}{
+		{nil, "done"},
+		{[]interface{}{"rows", 42}, "done rows=42"},
+		{[]interface{}{"rows", 42, "table", "users"}, "done rows=42 table=users"},
+		{[]interface{}{"rows", 42, "orphan"}, "done rows=42 !BADKEY=orphan"},
+	}
+	for _, c := range cases {
+		b := setup(t)
+		Error("done", c.kv...)
+		if got := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(b.String()), "error")); got != c.want {
+			t.Errorf("Error(%q, %v) : want %q, got %q", "done", c.kv, c.want, got)
+		}
+	}
+}
+
+func TestEffectFallback(t *testing.T) {
+	b := setup(t)
+	SetForceColor(true)
+	colors[Lerror] = "Hide"
+	t.Setenv("TERM", "dumb")
+	SetEffectFallback(true)
+	Error("boom")
+	if got := b.String(); !strings.Contains(got, paint("Bold", tags[Lerror])) || strings.Contains(got, paint("Hide", tags[Lerror])) {
+		t.Errorf("Hide should fall back to Bold on dumb terminal, got %q", got)
+	}
+	t.Setenv("TERM", "xterm-256color")
+	if got := setcolor("tag", Lerror, "boom"); got != paint("Hide", "boom") {
+		t.Errorf("Hide should be kept on capable terminal, got %q", got)
+	}
+}
+
+func TestExitMessage(t *testing.T) {
+	b := setup(t)
+	code := fakeExit(t)
+	SetVerbosity(Lsilent)
+	SetExitOnError(true)
+	Error("hidden cause")
+	if got := strings.TrimSpace(b.String()); *code != Lerror || got != "error     Immediate exit with code 4" {
+		t.Fatalf("exit reason should be shown at triggering level whatever verbosity, got exit %d, %q", *code, got)
+	}
+	b.Reset()
+	SetExitMessage("bye (%d)")
+	Critical("down")
+	if got := b.String(); !strings.Contains(got, "critical  bye (3)") {
+		t.Errorf("custom exit message not used, got %q", got)
+	}
+}
+
+func TestColorOutputs(t *testing.T) {
+	b := setup(t)
+	var tty, file bytes.Buffer
+	AddColorOutput(&tty)
+	AddOutput(&file)
+	Error("boom")
+	if !strings.Contains(tty.String(), "\x1b[") {
+		t.Errorf("colorized output should get escapes, got %q", tty.String())
+	}
+	if strings.Contains(file.String(), "\x1b[") || strings.Contains(b.String(), "\x1b[") {
+		t.Errorf("plain outputs should not get escapes, got %q and %q", file.String(), b.String())
+	}
+}
+
+func TestAudit(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lsilent)
+	Audit("login", "user", "bob")
+	if got := strings.TrimSpace(b.String()); got != "audit     login user=bob" {
+		t.Fatalf("audit should be logged whatever verbosity, got %q", got)
+	}
+	b.Reset()
+	var audit bytes.Buffer
+	SetAuditOutput(&audit)
+	ch, unsubscribe := Subscribe()
+	defer unsubscribe()
+	Audit("logout")
+	if b.Len() != 0 || !strings.Contains(audit.String(), "logout") {
+		t.Errorf("audit should go to audit output only, got %q and %q", b.String(), audit.String())
+	}
+	select {
+	case got := <-ch:
+		if !strings.Contains(got, "logout") {
+			t.Errorf("unexpected subscribed entry %q", got)
+		}
+	default:
+		t.Errorf("audit should reach subscribers")
+	}
+}
+
+func BenchmarkLog(b *testing.B) {
+	for _, caller := range []bool{false, true} {
+		b.Run(fmt.Sprintf("TraceCaller=%v", caller), func(b *testing.B) {
+			setup(b)
+			SetOutput(io.Discard)
+			SetTraceCaller(caller)
+			SetCallerMinLevel(Lsilent)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Error("boom")
+			}
+		})
+	}
+}
+
+func TestLogErrorTagged(t *testing.T) {
+	b := setup(t)
+	err := fmt.Errorf("query users: %w", context.DeadlineExceeded)
+	LogErrorTagged(Lerror, err, map[error]string{context.DeadlineExceeded: "timeout", io.EOF: "eof"})
+	if got := b.String(); !strings.Contains(got, "query users: context deadline exceeded kind=timeout") || strings.Contains(got, "eof") {
+		t.Errorf("want timeout label only, got %q", got)
+	}
+}
+
+func TestCallerDepth(t *testing.T) {
+	b := setup(t)
+	SetFlags(Llongfile)
+	SetCallerMinLevel(Lsilent)
+	SetCallerDepth(2)
+	Error("boom")
+	_, file, line, _ := runtime.Caller(0)
+	want := fmt.Sprintf("%s/%s:%d", path.Base(path.Dir(file)), path.Base(file), line-1)
+	if got := b.String(); !strings.Contains(got, " "+want) {
+		t.Errorf("want caller %q, got %q", want, got)
+	}
+}
+
+func TestWithMaxLevel(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Ldebug)
+	restore := WithMaxLevel(Ldebug)
+	Error("capped")
+	restore()
+	Error("restored")
+	ls := lines(b)
+	if len(ls) != 2 || strings.TrimSpace(ls[0]) != "debug     capped" || strings.TrimSpace(ls[1]) != "error     restored" {
+		t.Errorf("error should render as debug within block only, got %q", b.String())
+	}
+}
+
+func TestColorizePart(t *testing.T) {
+	setup(t)
+	before := map[string]bool{}
+	for p, on := range parts {
+		before[p] = on
+	}
+	if err := SetColorizePart("log", !before["log"]); err != nil {
+		t.Fatal(err)
+	}
+	for p, on := range before {
+		if want := on != (p == "log"); IsPartColorized(p) != want {
+			t.Errorf("part %q : want %v, got %v", p, want, IsPartColorized(p))
+		}
+	}
+	if err := SetColorizePart("nope", true); err == nil {
+		t.Errorf("unknown part should be an error")
+	}
+}
+
+func TestSyntaxHighlight(t *testing.T) {
+	b := setup(t)
+	SetForceColor(true)
+	SetSyntaxHighlight(true)
+	msg := `took 42 ms for "users"`
+	Error(msg)
+	got := b.String()
+	if !strings.Contains(got, paint(colors[12], "42")) || !strings.Contains(got, paint(colors[13], `"users"`)) {
+		t.Errorf("number and quoted string should be highlighted, got %q", got)
+	}
+	if n := VisibleLen(highlight(msg)); n != len(msg) {
+		t.Errorf("highlight should not change visible width : %d, want %d", n, len(msg))
+	}
+}
+
+func TestSetStart(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lnotice)
+	now := time.Date(2023, 6, 3, 10, 0, 0, 0, time.UTC)
+	SetSinceFunc(func(t time.Time) time.Duration { return now.Sub(t) })
+	begin := now.Add(-1500 * time.Millisecond)
+	SetStart(begin)
+	if !StartTime().Equal(begin) {
+		t.Errorf("start time not set : %v", StartTime())
+	}
+	AllDone()
+	if got := strings.TrimSpace(b.String()); got != "notice    All done in : 1.5s" {
+		t.Errorf("AllDone should measure from custom start, got %q", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	b := setup(t)
+	for _, f := range []string{"a.txt", "b.txt"} {
+		Collect(Lerror, f+" processed")
+	}
+	Collect(Lerror, "c.txt failed")
+	if b.Len() != 0 {
+		t.Fatalf("collected lines should not be logged before flush, got %q", b.String())
+	}
+	FlushCollected(Lerror, "Summary :")
+	want := []string{"Summary :", "  - a.txt processed", "  - b.txt processed", "  - c.txt failed"}
+	ls := lines(b)
+	if len(ls) != len(want) {
+		t.Fatalf("want header and 3 bullets, got %q", b.String())
+	}
+	for i, l := range ls {
+		if !strings.HasSuffix(l, " "+want[i]) {
+			t.Errorf("line %d : want %q, got %q", i, want[i], l)
+		}
+	}
+	b.Reset()
+	FlushCollected(Lerror, "Again :")
+	if len(lines(b)) != 1 {
+		t.Errorf("buffer should be cleared after flush, got %q", b.String())
+	}
+}
+
+func TestCallerPackage(t *testing.T) {
+	b := setup(t)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lsilent)
+	SetCallerPackage(true)
+	Error("boom")
+	if got := b.String(); !strings.Contains(got, "github.com/crownedgrouse/slogan.TestCallerPackage:") {
+		t.Errorf("want package qualified function as caller, got %q", got)
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	b := setup(t)
+	SetForceColor(true)
+	SetSyntaxHighlight(true)
+	ch, unsubscribe := Subscribe()
+	defer unsubscribe()
+	Error("took 42 ms")
+	if !strings.Contains(b.String(), "\x1b[") {
+		t.Fatalf("output should be colorized, got %q", b.String())
+	}
+	if got := <-ch; strings.IndexByte(got, 0x1b) >= 0 || got != StripANSI(strings.TrimSuffix(b.String(), "\n")) {
+		t.Errorf("subscribed entry should be stripped of escapes, got %q", got)
+	}
+	if got := StripANSI("\x1b[1;31mred\x1b[0m and \x1b[4mplain"); got != "red and plain" {
+		t.Errorf("StripANSI : got %q", got)
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lnotice)
+	now := time.Date(2023, 6, 3, 23, 30, 0, 0, time.Local)
+	SetClock(func() time.Time { return now })
+	// window crossing midnight
+	if err := SetQuietHours(Lnotice, "22:00", "07:00"); err != nil {
+		t.Fatal(err)
+	}
+	for _, hm := range [][2]int{{23, 30}, {0, 0}, {6, 59}} {
+		now = time.Date(2023, 6, 3, hm[0], hm[1], 0, 0, time.Local)
+		Notice("spam")
+	}
+	Error("urgent")
+	now = time.Date(2023, 6, 4, 7, 0, 0, 0, time.Local)
+	Notice("morning")
+	ls := lines(b)
+	if len(ls) != 2 || !strings.HasSuffix(ls[0], "urgent") || !strings.HasSuffix(ls[1], "morning") {
+		t.Errorf("notices should be dropped within quiet hours only, got %q", b.String())
+	}
+	if n := QuietSuppressed(Lnotice); n != 3 {
+		t.Errorf("want 3 suppressed, got %d", n)
+	}
+	if err := SetQuietHours(Lnotice, "25:00", "07:00"); err == nil {
+		t.Errorf("invalid hour should be an error")
+	}
+}
+
+func TestCallerMinLevel(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Linfo)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lerror)
+	Error("boom")
+	Info("noise")
+	ls := lines(b)
+	if len(ls) != 2 || !strings.Contains(ls[0], "slogan_test.go:") || strings.Contains(ls[1], "slogan_test.go:") {
+		t.Errorf("caller should be shown for error only, got %q", b.String())
+	}
+}
+
+func TestLogFunc(t *testing.T) {
+	b := setup(t)
+	called := 0
+	fn := func() string {
+		called++
+		return "computed"
+	}
+	LogFunc(Linfo, fn)
+	if called != 0 || b.Len() != 0 {
+		t.Fatalf("fn should not be called below verbosity")
+	}
+	LogFunc(Lerror, fn)
+	if called != 1 || !strings.HasSuffix(strings.TrimSpace(b.String()), "computed") {
+		t.Errorf("fn should be called once and logged above verbosity, got %d calls, %q", called, b.String())
+	}
+}
+
+func TestTrailingNewline(t *testing.T) {
+	b := setup(t)
+	Error("trailing\n")
+	if got := b.String(); got != "   error     trailing\n" {
+		t.Errorf("trailing newline should not give a blank line, got %q", got)
+	}
+}
+
+func TestAlignMultiline(t *testing.T) {
+	b := setup(t)
+	SetAlignMultiline(true)
+	Error("one\ntwo")
+	if got := b.String(); got != "   error     one\n             two\n" {
+		t.Errorf("continuation line should be aligned under message, got %q", got)
+	}
+}
+
+func TestEmoji(t *testing.T) {
+	b := setup(t)
+	SetEmoji(true)
+	Error("boom")
+	if got := strings.TrimSpace(b.String()); !strings.HasPrefix(got, emojis[Lerror]+" error") {
+		t.Errorf("error emoji should precede tag, got %q", got)
+	}
+}
+
+func TestPrefixFunc(t *testing.T) {
+	b := setup(t)
+	SetPrefix("static: ")
+	n := 0
+	SetPrefixFunc(func() string {
+		n++
+		return fmt.Sprintf("req%d ", n)
+	})
+	Error("one")
+	Error("two")
+	ls := lines(b)
+	if len(ls) != 2 || !strings.HasPrefix(ls[0], "req1 ") || !strings.HasPrefix(ls[1], "req2 ") || strings.Contains(b.String(), "static") {
+		t.Errorf("prefix func should take precedence and be called per entry, got %q", b.String())
+	}
+}
+
+func TestPrefixFuncLogging(t *testing.T) {
+	b := setup(t)
+	inner := false
+	SetPrefixFunc(func() string {
+		// logging from prefix func must not deadlock
+		if !inner {
+			inner = true
+			Error("inner")
+		}
+		return "p "
+	})
+	Error("boom")
+	if ls := lines(b); len(ls) != 2 || !strings.HasSuffix(ls[0], "inner") || !strings.HasPrefix(ls[1], "p ") {
+		t.Errorf("unexpected entries %q", b.String())
+	}
+}
+
+func TestSummary(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lnotice)
+	for _, u := range []string{"a", "b", "c"} {
+		Error("fetch failed", SummaryKey("timeout"), "url", u)
+	}
+	Info("fetch failed", SummaryKey("dns"), "url", "d")
+	if strings.Contains(b.String(), "timeout") {
+		t.Fatalf("summary key should not be rendered, got %q", b.String())
+	}
+	b.Reset()
+	PrintSummary()
+	ls := lines(b)
+	if len(ls) != 2 || !strings.HasSuffix(ls[0], "timeout: 3") || !strings.HasSuffix(ls[1], "dns: 1") {
+		t.Errorf("want counts sorted descending, got %q", b.String())
+	}
+}
+
+func TestLogNoNewline(t *testing.T) {
+	b := setup(t)
+	LogNoNewline(Lerror, "partial")
+	if got := b.String(); got != "   error     partial" {
+		t.Fatalf("no trailing newline should be written, got %q", got)
+	}
+	b.Reset()
+	SetForceColor(true)
+	LogNoNewline(Lerror, "colored")
+	if got := b.String(); strings.HasSuffix(got, "\n") || !strings.Contains(got, "\x1b[") {
+		t.Errorf("escapes should still apply without newline, got %q", got)
+	}
+}
+
+func TestLogNoNewlineConcurrent(t *testing.T) {
+	b := setup(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			LogNoNewline(Lerror, "x")
+		}()
+		go func() {
+			defer wg.Done()
+			Error("line")
+		}()
+	}
+	wg.Wait()
+	if n := strings.Count(b.String(), "line\n"); n != 50 {
+		t.Errorf("other entries should keep their newline, got %d", n)
+	}
+}
+
+func TestLevelMask(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Ldebug)
+	SetLevelMask(MaskOf(Lerror, Ldebug))
+	Error("shown")
+	Warning("hidden")
+	Info("hidden")
+	Log(Ldebug, "details")
+	ls := lines(b)
+	if len(ls) != 2 || !strings.HasSuffix(ls[0], "shown") || !strings.HasSuffix(ls[1], "details") {
+		t.Errorf("only error and debug should be shown, got %q", b.String())
+	}
+	if MaskOf(Lerror, Ldebug) != 1<<4|1<<8 {
+		t.Errorf("unexpected mask %b", MaskOf(Lerror, Ldebug))
+	}
+}
+
+func TestVerbosityConcurrent(t *testing.T) {
+	setup(t)
+	SetOutput(io.Discard)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			SetVerbosity(Lerror + i%5)
+		}(i)
+		go func() {
+			defer wg.Done()
+			Warning("maybe")
+			_ = WouldLog(Linfo, "maybe")
+			_ = GetVerbosity()
+		}()
+	}
+	wg.Wait()
+	if v := GetVerbosity(); v < Lerror || v > Ldebug {
+		t.Errorf("unexpected verbosity %d", v)
+	}
+}
+
+func TestSink(t *testing.T) {
+	b := setup(t)
+	var got []string
+	SetSink(func(level int, rendered string) {
+		got = append(got, fmt.Sprintf("%d %s", level, StripANSI(rendered)))
+	})
+	Error("one")
+	Warning("two")
+	if b.Len() != 0 {
+		t.Errorf("sink should bypass output, got %q", b.String())
+	}
+	if len(got) != 2 || got[0] != "4    error     one" || got[1] != "5    warning   two" {
+		t.Errorf("sink should receive rendered lines, got %q", got)
+	}
+}
+
+func TestSinkLogging(t *testing.T) {
+	setup(t)
+	var got []string
+	SetSink(func(level int, rendered string) {
+		got = append(got, rendered)
+		// logging from sink must not deadlock
+		if level == Lerror {
+			Warning("from sink")
+		}
+	})
+	Error("boom")
+	if len(got) != 2 {
+		t.Errorf("want 2 entries, got %q", got)
+	}
+}
+
+func TestDiffColors(t *testing.T) {
+	b := setup(t)
+	SetForceColor(true)
+	SetDiffColors(true)
+	Error("+added\n-removed")
+	got := b.String()
+	if !strings.Contains(got, color.Green("+added")) || !strings.Contains(got, color.Red("-removed")) {
+		t.Errorf("want green + line and red - line, got %q", got)
+	}
+	if n := VisibleLen(diffColor("+added\n-removed")); n != len("+added\n-removed") {
+		t.Errorf("diff colors should not change visible width : %d", n)
+	}
+}
+
+func TestTagWidth(t *testing.T) {
+	setup(t)
+	if w := TagWidth(); w != len("emergency") {
+		t.Errorf("want width of longest tag, got %d", w)
+	}
+	n := tags
+	n[Lerror] = "catastrophic error"
+	SetTags(n)
+	if w := TagWidth(); w != len("catastrophic error") {
+		t.Errorf("width should follow SetTags, got %d", w)
+	}
+}
+
+func TestDropPattern(t *testing.T) {
+	b := setup(t)
+	if err := AddDropPattern(`/healthz`); err != nil {
+		t.Fatal(err)
+	}
+	Error("GET /healthz 200")
+	Error("GET /api 500")
+	Error("GET /healthz?full=1 200")
+	if got := b.String(); strings.Contains(got, "healthz") || !strings.Contains(got, "/api") {
+		t.Errorf("health checks should be dropped only, got %q", got)
+	}
+	if n := DroppedCount(); n != 2 {
+		t.Errorf("want 2 dropped, got %d", n)
+	}
+	ClearDropPatterns()
+	Error("GET /healthz 200")
+	if !strings.Contains(b.String(), "healthz") {
+		t.Errorf("nothing should be dropped after clear")
+	}
+	if err := AddDropPattern("("); err == nil {
+		t.Errorf("invalid pattern should be an error")
+	}
+}
+
+func TestSilentToHooks(t *testing.T) {
+	b := setup(t)
+	ch, unsubscribe := Subscribe()
+	defer unsubscribe()
+	SetSilentToHooks(true)
+	Silent("kept")
+	if b.Len() != 0 {
+		t.Errorf("silent message should not be written, got %q", b.String())
+	}
+	select {
+	case got := <-ch:
+		if !strings.HasSuffix(got, "kept") {
+			t.Errorf("want silent message for hooks, got %q", got)
+		}
+	default:
+		t.Errorf("silent message should reach hooks")
+	}
+}
+
+func TestWouldLog(t *testing.T) {
+	b := setup(t)
+	AddDropPattern(`/healthz`)
+	if WouldLog(Lerror, "GET /healthz") {
+		t.Errorf("dropped message should not be logged")
+	}
+	if !WouldLog(Lerror, "GET /api") || WouldLog(Linfo, "GET /api") {
+		t.Errorf("WouldLog should follow verbosity")
+	}
+	SetNoEmpty(true)
+	if WouldLog(Lerror, "") {
+		t.Errorf("empty message should not be logged with NoEmpty")
+	}
+	if b.Len() != 0 || DroppedCount() != 0 {
+		t.Errorf("WouldLog should neither emit nor count, got %q, %d dropped", b.String(), DroppedCount())
+	}
+}
+
+func TestHexdump(t *testing.T) {
+	b := setup(t)
+	Hexdump(Lerror, []byte("Hello, slogan!\x00\x01\x02\xffAB"))
+	want := []string{
+		"00000000  48 65 6c 6c 6f 2c 20 73  6c 6f 67 61 6e 21 00 01  |Hello, slogan!..|",
+		"00000010  02 ff 41 42                                       |..AB|",
+	}
+	ls := lines(b)
+	if len(ls) != 2 {
+		t.Fatalf("want 2 lines for 20 bytes, got %q", b.String())
+	}
+	for i, l := range ls {
+		if !strings.HasSuffix(l, " "+want[i]) {
+			t.Errorf("line %d : want %q, got %q", i, want[i], l)
+		}
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	b := setup(t)
+	SetForceColor(true)
+	if err := RegisterLevel(10, "verbose", "DarkGray"); err != nil {
+		t.Fatal(err)
+	}
+	SetVerbosity(Ltrace)
+	LogCustom(10, "hidden")
+	if b.Len() != 0 {
+		t.Fatalf("custom level should be gated by verbosity, got %q", b.String())
+	}
+	SetVerbosity(10)
+	LogCustom(10, "details")
+	if got := b.String(); !strings.Contains(got, paint("DarkGray", "verbose  ")) || !strings.HasSuffix(got, "details\n") {
+		t.Errorf("custom level should log with its tag and color, got %q", got)
+	}
+	for _, level := range []int{Linfo, Laudit, 12, 15} {
+		if err := RegisterLevel(level, "x", ""); err == nil {
+			t.Errorf("level %d should be reserved", level)
+		}
+	}
+	if err := RegisterLevel(16, "x", "Mauve"); err == nil {
+		t.Errorf("unknown color should be an error")
+	}
+}
+
+func TestUnregisteredLevel(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(20)
+	Log(20, "x")
+	if got := strings.TrimSpace(b.String()); got != "level20   x" {
+		t.Errorf("unregistered level should get a fallback tag, got %q", got)
+	}
+}
+
+func TestSetOutputNil(t *testing.T) {
+	b := setup(t)
+	SetOutput(nil)
+	Log(Lerror, "still")
+	ls := lines(b)
+	if len(ls) != 2 || !strings.Contains(ls[0], "warning") || strings.TrimSpace(ls[1]) != "error     still" {
+		t.Errorf("nil output should warn and keep prior writer, got %q", b.String())
+	}
+}
+
+func TestKeyValues(t *testing.T) {
+	b := setup(t)
+	KeyValues(Lerror, map[string]string{"port": "80", "hostname": "example"})
+	KeyValuesOrdered(Lerror, "b", "2", "long", "4", "lone")
+	want := []string{
+		"error     hostname : example",
+		"error     port     : 80",
+		"error     b    : 2",
+		"error     long : 4",
+		"error     lone :",
+	}
+	ls := lines(b)
+	if len(ls) != len(want) {
+		t.Fatalf("want %d entries, got %q", len(want), b.String())
+	}
+	for i := range want {
+		if got := strings.TrimSpace(ls[i]); got != want[i] {
+			t.Errorf("entry %d: want %q, got %q", i, want[i], got)
+		}
+	}
+}
+
+func TestKeyValuesCaller(t *testing.T) {
+	b := setup(t)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lsilent)
+	KeyValuesOrdered(Lerror, "k", "v")
+	_, file, line, _ := runtime.Caller(0)
+	want := fmt.Sprintf("%s:%d", path.Base(file), line-1)
+	if got := b.String(); !strings.Contains(got, want) {
+		t.Errorf("want caller %q, got %q", want, got)
+	}
+}
+
+func TestSinceFuncIndependentOfClock(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lnotice)
+	SetClock(func() time.Time { return time.Now().Add(-time.Hour) })
+	resetStart()
+	AllDone()
+	if got := b.String(); strings.Contains(got, "-") || strings.Contains(got, "1h") {
+		t.Fatalf("duration should be unaffected by wall clock jump, got %q", got)
+	}
+	b.Reset()
+	SetSinceFunc(func(time.Time) time.Duration { return 2 * time.Second })
+	AllDone()
+	if got, want := strings.TrimSpace(b.String()), "All done in : "+Duration(2*time.Second); !strings.HasSuffix(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestShowSeq(t *testing.T) {
+	b := setup(t)
+	SetShowSeq(true)
+	ResetSeq()
+	for i := 0; i < 3; i++ {
+		Error("x")
+	}
+	ls := lines(b)
+	if len(ls) != 3 {
+		t.Fatalf("want 3 entries, got %q", b.String())
+	}
+	for i, l := range ls {
+		if want := fmt.Sprintf("#%d ", i+1); !strings.HasPrefix(strings.TrimSpace(l), want) {
+			t.Errorf("entry %d: want sequence %q, got %q", i, want, l)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	setup(t)
+	Warning("w")
+	if HighestLevel() != Lwarning || ExitCode() != 0 {
+		t.Errorf("warning should not give an exit code, got %d / %d", HighestLevel(), ExitCode())
+	}
+	Error("e")
+	Warning("w")
+	if HighestLevel() != Lerror || ExitCode() == 0 {
+		t.Errorf("error should be highest level with exit code, got %d / %d", HighestLevel(), ExitCode())
+	}
+}
+
+func TestColorMode(t *testing.T) {
+	for _, c := range []struct {
+		mode     string
+		terminal bool
+		color    bool
+	}{
+		{"auto", false, false},
+		{"auto", true, true},
+		{"always", false, true},
+		{"never", true, false},
+	} {
+		b := setup(t)
+		if err := SetColorMode(c.mode); err != nil {
+			t.Fatal(err)
+		}
+		isTerminal = c.terminal
+		Error("x")
+		if got := strings.Contains(b.String(), "\x1b["); got != c.color || ColorMode() != c.mode {
+			t.Errorf("mode %s on terminal=%v: want color %v, got %q (mode %s)", c.mode, c.terminal, c.color, b.String(), ColorMode())
+		}
+	}
+	if err := SetColorMode("sometimes"); err == nil {
+		t.Errorf("unknown mode should be an error")
+	}
+}
+
+func TestCallerIgnore(t *testing.T) {
+	b := setup(t)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lsilent)
+	AddCallerIgnore("slogan_test.go")
+	Error("x")
+	if got := b.String(); strings.Contains(got, "slogan_test.go") || !strings.Contains(got, "testing.go:") {
+		t.Errorf("ignored file should be skipped for next frame, got %q", got)
+	}
+	b.Reset()
+	ClearCallerIgnores()
+	Error("y")
+	if got := b.String(); !strings.Contains(got, "slogan_test.go:") {
+		t.Errorf("caller should be reported once ignores are cleared, got %q", got)
+	}
+}
+
+func TestShowDelta(t *testing.T) {
+	b := setup(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(func() time.Time { return now })
+	SetShowDelta(true)
+	Error("first")
+	now = now.Add(1500 * time.Millisecond)
+	Error("second")
+	ls := lines(b)
+	if len(ls) != 2 || !strings.Contains(ls[0], "+0.000s ") || !strings.Contains(ls[1], "+1.500s ") {
+		t.Errorf("want deltas since previous entry, got %q", b.String())
+	}
+}
+
+func TestAssert(t *testing.T) {
+	b := setup(t)
+	code := fakeExit(t)
+	SetExitOnError(true)
+	Assert(true, "fine")
+	if b.Len() != 0 || *code != -1 {
+		t.Fatalf("true condition should be a no-op, got exit %d, %q", *code, b.String())
+	}
+	Assert(false, "broken")
+	if got := b.String(); *code != Lcritical || !strings.Contains(got, "critical") || !strings.Contains(got, "assertion failed: broken (") || !strings.Contains(got, "slogan_test.go:") {
+		t.Errorf("false condition should log critical with caller then exit, got exit %d, %q", *code, got)
+	}
+	b.Reset()
+	SetExitOnError(false)
+	defer func() {
+		if r := recover(); r != "panicking" {
+			t.Errorf("want panic without ExitOnError, got %v", r)
+		}
+	}()
+	Assert(false, "panicking")
+}
+
+func TestSanitize(t *testing.T) {
+	b := setup(t)
+	SetSanitize(true)
+	Error("a\nerror     forged\x1b[0m")
+	ls := lines(b)
+	if len(ls) != 1 || strings.TrimSpace(ls[0]) != `error     a\nerror     forged\x1b[0m` {
+		t.Errorf("control characters should be escaped, got %q", b.String())
+	}
+}
+
+func TestColorLog(t *testing.T) {
+	b := setup(t)
+	SetForceColor(true)
+	SetVerbosity(Linfo)
+	if err := ColorLog(Linfo, "Green", "done"); err != nil {
+		t.Fatal(err)
+	}
+	Info("plain")
+	ls := lines(b)
+	if len(ls) != 2 || !strings.Contains(ls[0], paint("Green", tagOf(Linfo))) || !strings.Contains(ls[1], paint("Purple", tagOf(Linfo))) {
+		t.Errorf("want green forced entry then purple one, got %q", b.String())
+	}
+	if err := ColorLog(Linfo, "Mauve", "x"); err == nil {
+		t.Errorf("unknown color should be an error")
+	}
+}
+
+func TestColorLogConcurrent(t *testing.T) {
+	b := setup(t)
+	SetForceColor(true)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ColorLog(Lerror, "Green", "green")
+		}()
+		go func() {
+			defer wg.Done()
+			Error("level")
+		}()
+	}
+	wg.Wait()
+	green := paint("Green", tagOf(Lerror))
+	for _, l := range lines(b) {
+		if strings.Contains(l, green) != strings.HasSuffix(l, "green") {
+			t.Fatalf("color should apply to its own call only, got %q", l)
+		}
+	}
+}
+
+func TestTimed(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Ldebug)
+	elapsed := 50 * time.Millisecond
+	SetSinceFunc(func(time.Time) time.Duration { return elapsed })
+	Timed("fast", 100*time.Millisecond)()
+	elapsed = 200 * time.Millisecond
+	Timed("slow", 100*time.Millisecond)()
+	ls := lines(b)
+	if len(ls) != 2 || strings.TrimSpace(ls[0]) != "debug     fast took "+Duration(50*time.Millisecond) ||
+		strings.TrimSpace(ls[1]) != "warning   slow took "+Duration(200*time.Millisecond)+", more than "+Duration(100*time.Millisecond) {
+		t.Errorf("want debug under threshold and warning above, got %q", b.String())
+	}
+}
+
+func TestStack(t *testing.T) {
+	frames := Stack(0, 2)
+	_, file, line, _ := runtime.Caller(0)
+	if len(frames) != 2 {
+		t.Fatalf("want 2 frames, got %d", len(frames))
+	}
+	if f := frames[0]; f.File != file || f.Line != line-1 || f.Func != "github.com/crownedgrouse/slogan.TestStack" {
+		t.Errorf("first frame should be the calling function, got %+v", f)
+	}
+	if frames := Stack(1, 1); len(frames) != 1 || frames[0].Func != "testing.tRunner" {
+		t.Errorf("skip should drop frames, got %+v", frames)
+	}
+}
+
+func TestEnvMaxLevel(t *testing.T) {
+	b := setup(t)
+	t.Setenv("SLOGAN_MAX_LEVEL", "warning")
+	clampOnce = sync.Once{}
+	SetVerbosity(Ldebug)
+	if got := GetVerbosity(); got != Lwarning {
+		t.Errorf("verbosity should be clamped to warning, got %d", got)
+	}
+	SetVerbosity(Ltrace)
+	SetVerbosity(Lerror)
+	if got := GetVerbosity(); got != Lerror {
+		t.Errorf("verbosity under maximum should be kept, got %d", got)
+	}
+	if ls := lines(b); len(ls) != 1 || !strings.HasSuffix(ls[0], "verbosity debug clamped to warning by SLOGAN_MAX_LEVEL") {
+		t.Errorf("want one-time clamp notice, got %q", b.String())
+	}
+}
+
+func TestLayout(t *testing.T) {
+	b := setup(t)
+	SetClock(func() time.Time { return time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC) })
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lsilent)
+	if err := SetLayout([]string{"time", "msg", "tag", "caller"}); err != nil {
+		t.Fatal(err)
+	}
+	Error("boom")
+	_, file, line, _ := runtime.Caller(0)
+	re := regexp.MustCompile(`^\s*12:30:00\.000 boom error\s+` + regexp.QuoteMeta(fmt.Sprintf("%s:%d", path.Base(file), line-1)))
+	if got := b.String(); !re.MatchString(got) {
+		t.Errorf("want time, message, tag then caller, got %q", got)
+	}
+	for _, order := range [][]string{{"tag", "msg"}, {"tag", "msg", "msg", "time"}, {"tag", "msg", "caller", "date"}} {
+		if err := SetLayout(order); err == nil {
+			t.Errorf("layout %q should be rejected", order)
+		}
+	}
+}
+
+func TestStdlibLogger(t *testing.T) {
+	b := setup(t)
+	l := StdlibLogger()
+	if l.Writer() != b {
+		t.Fatalf("returned logger should write to slogan output")
+	}
+	var other bytes.Buffer
+	l.SetOutput(&other)
+	Error("through")
+	if b.Len() != 0 || strings.TrimSpace(other.String()) != "error     through" {
+		t.Errorf("slogan should write through returned logger, got %q and %q", b.String(), other.String())
+	}
+}
+
+func TestRegisterLevelConcurrent(t *testing.T) {
+	setup(t)
+	SetVerbosity(20)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(level int) {
+			defer wg.Done()
+			RegisterLevel(level, fmt.Sprintf("custom%d", level), "Cyan")
+		}(16 + i)
+		go func(level int) {
+			defer wg.Done()
+			LogCustom(level, "x")
+			TagWidth()
+			levelFromName("custom16")
+		}(16 + i)
+	}
+	wg.Wait()
+}
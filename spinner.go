@@ -0,0 +1,65 @@
+package slogan
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Frames of spinner animation
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Delay between spinner frames
+var spinnerDelay = 100 * time.Millisecond
+
+// Animate a spinner next to msg on a single line, until returned function is called :
+// with ok=true, the spinner line is replaced by a success notice, otherwise by an error.
+// If output is not a terminal (or JSON), msg is logged as notice at start instead.
+// Calls of returned function after the first one do nothing, as for a context.CancelFunc.
+func Spinner(msg string) (stop func(ok bool)) {
+	var once sync.Once
+	end := func(ok bool) {
+		if ok {
+			Log(Lnotice, fmt.Sprintf(formats["spinok"], msg))
+		} else {
+			Log(Lerror, fmt.Sprintf(formats["spinfail"], msg))
+		}
+	}
+	outputsMu.Lock()
+	plain := !isTerminal || JSON == true || sink != nil
+	outputsMu.Unlock()
+	if plain {
+		Log(Lnotice, msg)
+		return func(ok bool) {
+			once.Do(func() { end(ok) })
+		}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(spinnerDelay)
+		defer t.Stop()
+		for i := 0; ; i++ {
+			outputsMu.Lock()
+			io.WriteString(output, "\r"+fmt.Sprintf(formats["spinner"], colorize(true, "tag", Lnotice, spinnerFrames[i%len(spinnerFrames)]), msg))
+			outputsMu.Unlock()
+			select {
+			case <-t.C:
+			case <-done:
+				outputsMu.Lock()
+				io.WriteString(output, "\r\x1b[K") // clear line
+				outputsMu.Unlock()
+				return
+			}
+		}
+	}()
+	return func(ok bool) {
+		once.Do(func() {
+			close(done)
+			<-stopped
+			end(ok)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package slogan
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpinnerNotTerminal(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lnotice)
+	before := runtime.NumGoroutine()
+	Spinner("fetching")(true)
+	Spinner("pushing")(false)
+	ls := lines(b)
+	want := []string{"notice    fetching", "notice    fetching : done", "notice    pushing", "error     pushing : failed"}
+	if len(ls) != len(want) {
+		t.Fatalf("want start and end lines, got %q", b.String())
+	}
+	for i, l := range ls {
+		if strings.TrimSpace(l) != want[i] {
+			t.Errorf("line %d : want %q, got %q", i, want[i], l)
+		}
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("no goroutine should be left, %d before, %d after", before, n)
+	}
+}
+
+func TestSpinnerTerminal(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lnotice)
+	isTerminal = true
+	spinnerDelay = time.Millisecond
+	defer func() { spinnerDelay = 100 * time.Millisecond }()
+	stop := Spinner("fetching")
+	time.Sleep(10 * time.Millisecond)
+	stop(true)
+	got := b.String()
+	if !strings.Contains(got, "\r") || !strings.Contains(got, "\r\x1b[K") || !strings.HasSuffix(got, "fetching : done\n") {
+		t.Errorf("spinner line should be animated, cleared then replaced by notice, got %q", got)
+	}
+}
+
+func TestSpinnerStopTwice(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Lnotice)
+	isTerminal = true
+	spinnerDelay = time.Millisecond
+	defer func() { spinnerDelay = 100 * time.Millisecond }()
+	stop := Spinner("fetching")
+	stop(true)
+	stop(false)
+	if got := b.String(); strings.Count(got, "fetching :") != 1 || strings.Contains(got, "failed") {
+		t.Errorf("second stop should do nothing, got %q", got)
+	}
+	b.Reset()
+	isTerminal = false
+	stop = Spinner("pushing")
+	stop(true)
+	stop(true)
+	if ls := lines(b); len(ls) != 2 {
+		t.Errorf("second stop should not log again, got %q", b.String())
+	}
+}
@@ -0,0 +1,125 @@
+package slogan
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Drop-in replacement of standard *log.Logger methods, routed through slogan.
+// Print* log as info, Fatal* as critical then exit, Panic* as critical then panic.
+type StdCompat struct{}
+
+// Return a standard logger replacement, e.g var log = slogan.NewStd()
+func NewStd() *StdCompat {
+	return &StdCompat{}
+}
+
+// A method is a single frame above Log, as level functions are above logKV : caller is the one of the method
+
+// Info log, formatted as fmt.Sprint
+func (l *StdCompat) Print(v ...interface{}) {
+	Log(Linfo, fmt.Sprint(v...))
+}
+
+// Info log, formatted as fmt.Sprintf
+func (l *StdCompat) Printf(format string, v ...interface{}) {
+	Log(Linfo, fmt.Sprintf(format, v...))
+}
+
+// Info log, formatted as fmt.Sprintln
+func (l *StdCompat) Println(v ...interface{}) {
+	Log(Linfo, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// Critical log formatted as fmt.Sprint, then exit
+func (l *StdCompat) Fatal(v ...interface{}) {
+	Log(Lcritical, fmt.Sprint(v...))
+	if ExitOnError == false {
+		Flush()
+		ExitFunc(Lcritical)
+	}
+}
+
+// Critical log formatted as fmt.Sprintf, then exit
+func (l *StdCompat) Fatalf(format string, v ...interface{}) {
+	Log(Lcritical, fmt.Sprintf(format, v...))
+	if ExitOnError == false {
+		Flush()
+		ExitFunc(Lcritical)
+	}
+}
+
+// Critical log formatted as fmt.Sprintln, then exit
+func (l *StdCompat) Fatalln(v ...interface{}) {
+	Log(Lcritical, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+	if ExitOnError == false {
+		Flush()
+		ExitFunc(Lcritical)
+	}
+}
+
+// Critical log formatted as fmt.Sprint, then panic with message
+func (l *StdCompat) Panic(v ...interface{}) {
+	s := fmt.Sprint(v...)
+	Log(Lcritical, s)
+	panic(s)
+}
+
+// Critical log formatted as fmt.Sprintf, then panic with message
+func (l *StdCompat) Panicf(format string, v ...interface{}) {
+	s := fmt.Sprintf(format, v...)
+	Log(Lcritical, s)
+	panic(s)
+}
+
+// Critical log formatted as fmt.Sprintln, then panic with message
+func (l *StdCompat) Panicln(v ...interface{}) {
+	s := fmt.Sprintln(v...)
+	Log(Lcritical, strings.TrimSuffix(s, "\n"))
+	panic(s)
+}
+
+// Info log of s, calldepth is ignored (see SetCallerDepth)
+func (l *StdCompat) Output(calldepth int, s string) error {
+	Log(Linfo, s)
+	return nil
+}
+
+// Flags of legacy logger, with Lshortfile or Llongfile if caller is traced
+func (l *StdCompat) Flags() int {
+	flag := logger.Flags()
+	if TraceCaller == true {
+		if CallerBase == true {
+			flag |= Lshortfile
+		} else {
+			flag |= Llongfile
+		}
+	}
+	return flag
+}
+
+// Set flags, as SetFlags
+func (l *StdCompat) SetFlags(flag int) {
+	SetFlags(flag)
+}
+
+// Prefix of log entries
+func (l *StdCompat) Prefix() string {
+	return tags[0]
+}
+
+// Set prefix of log entries, as SetPrefix
+func (l *StdCompat) SetPrefix(prefix string) {
+	SetPrefix(prefix)
+}
+
+// Current output
+func (l *StdCompat) Writer() io.Writer {
+	return output
+}
+
+// Set output, as SetOutput
+func (l *StdCompat) SetOutput(w io.Writer) {
+	SetOutput(w)
+}
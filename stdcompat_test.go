@@ -0,0 +1,54 @@
+package slogan
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStdCompatPrintf(t *testing.T) {
+	b := setup(t)
+	SetVerbosity(Linfo)
+	log := NewStd()
+	log.Printf("n=%d", 1)
+	if got := strings.TrimSpace(b.String()); got != "info      n=1" {
+		t.Errorf("want info entry, got %q", got)
+	}
+}
+
+func TestStdCompatFatalf(t *testing.T) {
+	b := setup(t)
+	code := fakeExit(t)
+	NewStd().Fatalf("bad %s", "thing")
+	if *code != Lcritical || !strings.HasSuffix(strings.TrimSpace(b.String()), "bad thing") {
+		t.Errorf("want critical entry then exit, got exit %d, %q", *code, b.String())
+	}
+}
+
+func TestStdCompatPanicln(t *testing.T) {
+	b := setup(t)
+	defer func() {
+		if r := recover(); r != "a b\n" {
+			t.Errorf("want panic with message, got %v", r)
+		}
+		if !strings.HasSuffix(b.String(), "a b\n") {
+			t.Errorf("want critical entry before panic, got %q", b.String())
+		}
+	}()
+	NewStd().Panicln("a", "b")
+}
+
+func TestStdCompatCaller(t *testing.T) {
+	b := setup(t)
+	SetFlags(Lshortfile)
+	SetCallerMinLevel(Lsilent)
+	SetVerbosity(Linfo)
+	NewStd().Print("x")
+	_, file, line, _ := runtime.Caller(0)
+	want := fmt.Sprintf("%s:%d", path.Base(file), line-1)
+	if got := b.String(); !strings.Contains(got, want) {
+		t.Errorf("want caller %q, got %q", want, got)
+	}
+}
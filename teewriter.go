@@ -0,0 +1,98 @@
+package slogan
+
+import (
+	"io"
+	"log"
+	"sync"
+)
+
+// Writer of a TeeWriter, with its own legacy logger
+type out struct {
+	w      io.Writer
+	l      *log.Logger
+	color  bool   // whether w gets colors
+	format string // format of entries written by slogan, "" meaning global one
+}
+
+// io.Writer duplicating writes to several writers, each one tagged colorized or plain :
+// ANSI sequences are stripped for plain writers. The zero value is an empty TeeWriter.
+// Additional outputs of slogan are held in a TeeWriter, each one being rendered according its tag.
+type TeeWriter struct {
+	mu      sync.Mutex
+	writers []*out
+}
+
+// Return an empty TeeWriter
+func NewTeeWriter() *TeeWriter {
+	return &TeeWriter{}
+}
+
+// Add a writer, getting colors if color=true, plain text otherwise
+func (t *TeeWriter) Add(w io.Writer, color bool) {
+	t.add(w, color, "")
+}
+
+// Add a writer with its own format of slogan entries
+func (t *TeeWriter) add(w io.Writer, color bool, format string) {
+	if w == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.writers = append(t.writers, &out{w: w, l: log.New(w, "", logger.Flags()), color: color, format: format})
+}
+
+// Remove a writer
+func (t *TeeWriter) Remove(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, o := range t.writers {
+		if o.w == w {
+			t.writers = append(t.writers[:i:i], t.writers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Number of writers
+func (t *TeeWriter) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.writers)
+}
+
+// Write p to every writer, without colors for plain ones.
+// All writers are written, first error being returned.
+func (t *TeeWriter) Write(p []byte) (int, error) {
+	var err error
+	var plain []byte
+	for _, o := range t.targets() {
+		b := p
+		if !o.color {
+			if plain == nil {
+				plain = []byte(StripANSI(string(p)))
+			}
+			b = plain
+		}
+		if _, e := o.w.Write(b); e != nil && err == nil {
+			err = e
+		}
+	}
+	return len(p), err
+}
+
+// Snapshot of writers
+func (t *TeeWriter) targets() []*out {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*out(nil), t.writers...)
+}
+
+// Remove all writers and return them
+func (t *TeeWriter) clear() []*out {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ws := t.writers
+	t.writers = nil
+	return ws
+}
@@ -0,0 +1,48 @@
+package slogan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bclicn/color"
+)
+
+// Writer always failing
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestTeeWriter(t *testing.T) {
+	var colored, plain bytes.Buffer
+	tee := NewTeeWriter()
+	tee.Add(&colored, true)
+	tee.Add(&plain, false)
+	msg := color.Red("boom") + "\n"
+	if n, err := fmt.Fprint(tee, msg); err != nil || n != len(msg) {
+		t.Fatalf("Write : %d, %v", n, err)
+	}
+	if colored.String() != msg {
+		t.Errorf("colorized writer should get escapes, got %q", colored.String())
+	}
+	if plain.String() != "boom\n" {
+		t.Errorf("plain writer should get stripped text, got %q", plain.String())
+	}
+	tee.Remove(&colored)
+	if tee.Len() != 1 {
+		t.Errorf("want 1 writer after Remove, got %d", tee.Len())
+	}
+}
+
+func TestTeeWriterError(t *testing.T) {
+	var b bytes.Buffer
+	tee := NewTeeWriter()
+	tee.Add(failWriter{}, false)
+	tee.Add(&b, false)
+	if _, err := tee.Write([]byte("x")); err == nil || b.String() != "x" {
+		t.Errorf("all writers should be written and first error returned, got %v, %q", err, b.String())
+	}
+}
@@ -0,0 +1,89 @@
+package slogan
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cached terminal width, 0 until first read
+var termWidth atomic.Uint32
+
+// Stop function of resize watcher, if any
+var stopResize func()
+var resizeMu sync.Mutex
+
+// Get terminal width, read once then cached. See WatchResize for long-running programs.
+func TerminalWidth() uint {
+	if w := termWidth.Load(); w != 0 {
+		return uint(w)
+	}
+	return refreshWidth()
+}
+
+// Read terminal width again and cache it
+func refreshWidth() uint {
+	w := getWidth()
+	termWidth.Store(uint32(w))
+	return w
+}
+
+// Refresh cached terminal width when terminal is resized (SIGWINCH, Unix only),
+// until returned function is called or Close. A former watcher is stopped.
+func WatchResize() func() {
+	resizeMu.Lock()
+	defer resizeMu.Unlock()
+	if stopResize != nil {
+		stopResize()
+	}
+	var once sync.Once
+	stop := watchResize()
+	stopResize = func() { once.Do(stop) }
+	return stopResize
+}
+
+// Stop resize watcher, if any
+func unwatchResize() {
+	resizeMu.Lock()
+	defer resizeMu.Unlock()
+	if stopResize != nil {
+		stopResize()
+		stopResize = nil
+	}
+}
+
+// Maximum wait for terminal answer to background query
+var backgroundTimeout = 100 * time.Millisecond
+
+// Background color in answer to OSC 11 query, e.g "\x1b]11;rgb:ffff/ffff/dddd\x07"
+var backgroundRe = regexp.MustCompile(`\]11;rgb:([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})`)
+
+// Detect terminal background brightness, "dark" or "light", by querying its color (OSC 11, Unix only).
+// Gives up, returning false, if there is no terminal or no answer in time.
+func DetectBackground() (string, bool) {
+	answer, ok := queryBackground(backgroundTimeout)
+	if !ok {
+		return "", false
+	}
+	return parseBackground(answer)
+}
+
+// Brightness of background from answer to OSC 11 query
+func parseBackground(answer string) (string, bool) {
+	m := backgroundRe.FindStringSubmatch(answer)
+	if m == nil {
+		return "", false
+	}
+	rgb := [3]float64{}
+	for i, c := range m[1:] {
+		v, _ := strconv.ParseUint(c, 16, 16)
+		rgb[i] = float64(v) / float64(uint64(1)<<(4*len(c))-1)
+	}
+	// perceived luminance
+	if 0.299*rgb[0]+0.587*rgb[1]+0.114*rgb[2] < 0.5 {
+		return "dark", true
+	}
+	return "light", true
+}
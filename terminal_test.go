@@ -0,0 +1,24 @@
+package slogan
+
+import (
+	"testing"
+)
+
+func TestParseBackground(t *testing.T) {
+	cases := map[string]string{
+		"\x1b]11;rgb:ffff/ffff/dddd\x07":   "light",
+		"\x1b]11;rgb:0000/0000/0000\x1b\\": "dark",
+		"\x1b]11;rgb:1e/1e/2e\x07":         "dark",
+		"\x1b]11;rgb:f/f/f\x07":            "light",
+	}
+	for answer, want := range cases {
+		if got, ok := parseBackground(answer); !ok || got != want {
+			t.Errorf("parseBackground(%q) = %q, %v, want %q", answer, got, ok, want)
+		}
+	}
+	for _, answer := range []string{"", "\x1b[0n", "\x1b]11;?\x07"} {
+		if _, ok := parseBackground(answer); ok {
+			t.Errorf("parseBackground(%q) should fail", answer)
+		}
+	}
+}
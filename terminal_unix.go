@@ -0,0 +1,100 @@
+//go:build !windows
+
+package slogan
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Terminal size structure
+type winsize struct {
+	Row    uint16
+	Col    uint16
+	Xpixel uint16
+	Ypixel uint16
+}
+
+// Nothing to set up on Unix terminals, ANSI escape sequences being supported
+func initTerminal() {}
+
+// Get terminal width, 80 if stdin is not a terminal
+func getWidth() uint {
+	ws := &winsize{}
+	retCode, _, _ := syscall.Syscall(syscall.SYS_IOCTL,
+		uintptr(syscall.Stdin),
+		uintptr(syscall.TIOCGWINSZ),
+		uintptr(unsafe.Pointer(ws)))
+
+	if int(retCode) == -1 || ws.Col == 0 {
+		return 80
+	}
+	return uint(ws.Col)
+}
+
+// Refresh cached width on each SIGWINCH, return a function stopping it
+func watchResize() func() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				refreshWidth()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// Query background color of controlling terminal, return its answer.
+// Reading is synchronous with a deadline, false is returned if terminal does not support deadlines.
+func queryBackground(timeout time.Duration) (string, bool) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", false
+	}
+	defer tty.Close()
+	// Fd would switch tty to blocking mode, disabling deadlines
+	rc, err := tty.SyscallConn()
+	if err != nil {
+		return "", false
+	}
+	var fd int
+	rc.Control(func(f uintptr) { fd = int(f) })
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return "", false
+	}
+	defer terminal.Restore(fd, state)
+	if err := tty.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return "", false
+	}
+	if _, err := tty.WriteString("\x1b]11;?\x07"); err != nil {
+		return "", false
+	}
+	// answer ends with BEL or ST (ESC \)
+	b := make([]byte, 0, 64)
+	c := make([]byte, 1)
+	for len(b) < cap(b) {
+		if n, err := tty.Read(c); err != nil || n == 0 {
+			return "", false
+		}
+		b = append(b, c[0])
+		if c[0] == '\a' || (c[0] == '\\' && len(b) > 1 && b[len(b)-2] == 0x1b) {
+			break
+		}
+	}
+	return string(b), true
+}
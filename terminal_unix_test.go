@@ -0,0 +1,27 @@
+//go:build !windows
+
+package slogan
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWatchResize(t *testing.T) {
+	stop := WatchResize()
+	defer stop()
+	// stale cached width
+	termWidth.Store(1)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGWINCH); err != nil {
+		t.Fatal(err)
+	}
+	want := getWidth()
+	deadline := time.Now().Add(time.Second)
+	for TerminalWidth() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("cached width not re-read on SIGWINCH : %d, want %d", TerminalWidth(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
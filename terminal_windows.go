@@ -0,0 +1,52 @@
+//go:build windows
+
+package slogan
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// Enable ANSI escape sequences on Windows 10+ consoles.
+// Color is disabled on stderr if virtual terminal processing cannot be enabled.
+// Called by init before defaults are captured, so that Reset keeps the result.
+func initTerminal() {
+	if isTerminal && !enableVirtualTerminal(os.Stderr) {
+		isTerminal = false
+	}
+	enableVirtualTerminal(os.Stdout)
+}
+
+// Enable virtual terminal processing on a console file, return whether it succeeded
+func enableVirtualTerminal(f *os.File) bool {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return false // not a console
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	return windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
+
+// Get terminal width
+func getWidth() uint {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(os.Stdout.Fd()), &info); err != nil {
+		return 80
+	}
+	return uint(info.Window.Right - info.Window.Left + 1)
+}
+
+// No SIGWINCH on Windows : width is read once
+func watchResize() func() {
+	return func() {}
+}
+
+// Background query is not supported on Windows consoles
+func queryBackground(timeout time.Duration) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package slogan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnableVirtualTerminalNotConsole(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if enableVirtualTerminal(f) {
+		t.Errorf("a file is not a console")
+	}
+}
+
+func TestResetKeepsVirtualTerminal(t *testing.T) {
+	Reset()
+	t.Cleanup(Reset)
+	if !enableVirtualTerminal(os.Stderr) && isTerminal {
+		t.Errorf("Reset should not colorize stderr without virtual terminal processing")
+	}
+}
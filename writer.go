@@ -0,0 +1,108 @@
+package slogan
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Level keywords recognized besides tags, i.e at beginning of lines by PrefixParsingWriter
+var levelKeywords = map[string]int{
+	"silent":  Lsilent,
+	"emerg":   Lemergency,
+	"fatal":   Lcritical,
+	"crit":    Lcritical,
+	"err":     Lerror,
+	"warn":    Lwarning,
+	"dbg":     Ldebug,
+	"verbose": Ltrace,
+}
+
+// Output writer receiving level of each entry, e.g to map it to a severity of its own
+type levelWriter interface {
+	writeLevel(level int, entry string) error
+}
+
+// Writer logging each line at level found in its prefix
+type prefixWriter struct {
+	level int
+	plain bool // whether prefixes are ignored, all lines being logged at level
+	buf   []byte
+	mu    sync.Mutex
+}
+
+// Return an io.WriteCloser logging each written line, for instance output of a subprocess.
+// A line prefixed with a level keyword followed by a colon (e.g "ERROR: boom", case insensitive)
+// is logged at that level without prefix, any other line at defaultLevel.
+// Close logs a last line without trailing newline, if any.
+func PrefixParsingWriter(defaultLevel int) io.WriteCloser {
+	return &prefixWriter{level: defaultLevel}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(w.buf[:i]), "\r")
+		w.buf = w.buf[i+1:]
+		w.log(line)
+	}
+	return len(p), nil
+}
+
+// Log a remaining line without trailing newline, if any
+func (w *prefixWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.log(strings.TrimSuffix(string(w.buf), "\r"))
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *prefixWriter) log(line string) {
+	if w.plain {
+		Log(w.level, line)
+		return
+	}
+	Log(parseLevel(line, w.level))
+}
+
+// Find level of a line from its prefix, return level and line without prefix
+func parseLevel(line string, defaultLevel int) (int, string) {
+	i := strings.IndexByte(line, ':')
+	if i <= 0 {
+		return defaultLevel, line
+	}
+	if level, ok := levelFromName(line[:i]); ok {
+		return level, strings.TrimLeft(line[i+1:], " ")
+	}
+	return defaultLevel, line
+}
+
+// Find level from its tag or a common abbreviation, case insensitive
+func levelFromName(name string) (int, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for level := Lemergency; level <= Ltrace; level++ {
+		if name == strings.TrimSpace(tags[level]) {
+			return level, true
+		}
+	}
+	customLevelsMu.RLock()
+	for level, c := range customLevels {
+		if name == strings.ToLower(strings.TrimSpace(c.tag)) {
+			customLevelsMu.RUnlock()
+			return level, true
+		}
+	}
+	customLevelsMu.RUnlock()
+	level, ok := levelKeywords[name]
+	return level, ok
+}
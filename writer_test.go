@@ -0,0 +1,70 @@
+package slogan
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPrefixParsingWriter(t *testing.T) {
+	b := setup(t)
+	if _, err := io.WriteString(PrefixParsingWriter(Lwarning), "ERROR: boom\nhello\n"); err != nil {
+		t.Fatal(err)
+	}
+	ls := lines(b)
+	if len(ls) != 2 {
+		t.Fatalf("want 2 entries, got %q", b.String())
+	}
+	if got := strings.TrimSpace(ls[0]); got != "error     boom" {
+		t.Errorf("prefixed line should be logged as error without prefix, got %q", got)
+	}
+	if got := strings.TrimSpace(ls[1]); got != "warning   hello" {
+		t.Errorf("other line should be logged at default level, got %q", got)
+	}
+}
+
+// Writer recording levels of entries
+type levelRecorder struct {
+	levels  []int
+	entries []string
+}
+
+func (w *levelRecorder) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *levelRecorder) writeLevel(level int, entry string) error {
+	w.levels = append(w.levels, level)
+	w.entries = append(w.entries, entry)
+	return nil
+}
+
+func TestLevelWriter(t *testing.T) {
+	setup(t)
+	var w levelRecorder
+	SetOutput(&w)
+	Error("boom")
+	Warning("careful")
+	if len(w.levels) != 2 || w.levels[0] != Lerror || w.levels[1] != Lwarning {
+		t.Fatalf("writer should get level of each entry, got %v", w.levels)
+	}
+	if got := strings.TrimSpace(w.entries[0]); got != "error     boom" || !strings.HasSuffix(w.entries[0], "\n") {
+		t.Errorf("unexpected entry %q", w.entries[0])
+	}
+}
+
+func TestPrefixParsingWriterClose(t *testing.T) {
+	b := setup(t)
+	w := PrefixParsingWriter(Lwarning)
+	io.WriteString(w, "ERROR: boom\nERROR: partial")
+	if ls := lines(b); len(ls) != 1 {
+		t.Fatalf("partial line should wait for Close, got %q", b.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ls := lines(b)
+	if len(ls) != 2 || strings.TrimSpace(ls[1]) != "error     partial" {
+		t.Errorf("Close should log pending line, got %q", b.String())
+	}
+}